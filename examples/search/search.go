@@ -157,20 +157,10 @@ func search(ctx context.Context, c *tzpro.Client) error {
 		WithColumns("id", "hash", "parameters", "big_map_diff", "is_contract", "receiver")
 
 	plog := log.NewProgressLogger(log.Log)
-	var (
-		cursor uint64
-		count  int
-	)
-	for {
-		q.WithCursor(cursor)
-		log.Infof("Fetching calls from id %d...", cursor)
-		ops, err := q.Run(ctx)
-		if err != nil {
-			return err
-		}
-		if ops.Len() == 0 {
-			break
-		}
+	var count int
+	pager := q.Pages()
+	for pager.Next(ctx) {
+		ops := pager.Page()
 		if err := c.Op.ResolveTypes(ctx, ops.Rows()...); err != nil {
 			return err
 		}
@@ -235,7 +225,9 @@ func search(ctx context.Context, c *tzpro.Client) error {
 			}
 		}
 		plog.Log(ops.Len())
-		cursor = ops.Cursor()
+	}
+	if err := pager.Err(); err != nil {
+		return err
 	}
 	log.Infof("Processed %d calls", count)
 	return nil
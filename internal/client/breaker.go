@@ -0,0 +1,229 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker for a single host.
+type BreakerState byte
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "invalid"
+	}
+}
+
+var (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerTimeout   = 30 * time.Second
+)
+
+// CircuitBreaker stops sending requests to a host after repeated network
+// or 5xx failures. While open, requests fail fast with ErrCircuitOpen.
+// After OpenTimeout a limited number of trial requests is let through
+// (half-open). A successful trial closes the circuit, a failed one opens
+// it again.
+type CircuitBreaker struct {
+	Threshold   int           // consecutive failures that open the circuit
+	OpenTimeout time.Duration // time until a trial request is allowed
+	MaxTrials   int           // concurrent trial requests when half-open
+
+	mu    sync.Mutex
+	hosts map[string]*hostBreaker
+}
+
+type hostBreaker struct {
+	state    BreakerState
+	failures int
+	until    time.Time
+	trials   int
+}
+
+// NewCircuitBreaker creates a breaker that opens after threshold
+// consecutive failures and tries again after timeout.
+func NewCircuitBreaker(threshold int, timeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold:   threshold,
+		OpenTimeout: timeout,
+		MaxTrials:   1,
+		hosts:       make(map[string]*hostBreaker),
+	}
+}
+
+// State returns the current state of the circuit for host.
+func (b *CircuitBreaker) State(host string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h, ok := b.hosts[host]
+	if !ok {
+		return BreakerClosed
+	}
+	if h.state == BreakerOpen && !time.Now().Before(h.until) {
+		return BreakerHalfOpen
+	}
+	return h.state
+}
+
+// Reset closes the circuit for host.
+func (b *CircuitBreaker) Reset(host string) {
+	b.mu.Lock()
+	delete(b.hosts, host)
+	b.mu.Unlock()
+}
+
+// breakerOutcome classifies a request result for the circuit breaker.
+type breakerOutcome byte
+
+const (
+	breakerSuccess breakerOutcome = iota
+	breakerFailure
+	breakerIgnore // canceled by caller, neither success nor failure
+)
+
+// allow checks whether a request to host may be sent. On success the
+// caller must report the outcome by calling done exactly once.
+func (b *CircuitBreaker) allow(host string) (done func(breakerOutcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.hosts == nil {
+		b.hosts = make(map[string]*hostBreaker)
+	}
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &hostBreaker{}
+		b.hosts[host] = h
+	}
+	switch h.state {
+	case BreakerOpen:
+		if time.Now().Before(h.until) {
+			return nil, &ErrCircuitOpen{Host: host, deadline: h.until}
+		}
+		h.state = BreakerHalfOpen
+		h.trials = 0
+		fallthrough
+	case BreakerHalfOpen:
+		max := b.MaxTrials
+		if max < 1 {
+			max = 1
+		}
+		if h.trials >= max {
+			return nil, &ErrCircuitOpen{Host: host, deadline: time.Now().Add(b.timeout())}
+		}
+		h.trials++
+		return func(o breakerOutcome) { b.report(h, true, o) }, nil
+	default:
+		return func(o breakerOutcome) { b.report(h, false, o) }, nil
+	}
+}
+
+func (b *CircuitBreaker) report(h *hostBreaker, trial bool, o breakerOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if trial {
+		h.trials--
+	}
+	switch o {
+	case breakerIgnore:
+		return
+	case breakerSuccess:
+		if trial || h.state == BreakerClosed {
+			h.state = BreakerClosed
+			h.failures = 0
+		}
+		return
+	}
+	h.failures++
+	threshold := b.Threshold
+	if threshold < 1 {
+		threshold = DefaultBreakerThreshold
+	}
+	if trial || (h.state == BreakerClosed && h.failures >= threshold) {
+		h.state = BreakerOpen
+		h.until = time.Now().Add(b.timeout())
+	}
+}
+
+func (b *CircuitBreaker) timeout() time.Duration {
+	if b.OpenTimeout <= 0 {
+		return DefaultBreakerTimeout
+	}
+	return b.OpenTimeout
+}
+
+// classifyBreaker classifies a request outcome. Network errors and 5xx
+// responses count as upstream failure, canceled requests are ignored.
+func classifyBreaker(req *http.Request, resp *http.Response, err error) breakerOutcome {
+	switch {
+	case req.Context().Err() != nil:
+		return breakerIgnore
+	case err != nil && isNetError(err):
+		return breakerFailure
+	case err == nil && resp.StatusCode >= 500:
+		return breakerFailure
+	default:
+		return breakerSuccess
+	}
+}
+
+// ErrCircuitOpen is returned without contacting the server while the
+// circuit for a host is open.
+type ErrCircuitOpen struct {
+	Host     string
+	deadline time.Time
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit open for %s, retry in %s", e.Host, time.Until(e.deadline).Round(time.Millisecond))
+}
+
+// Deadline returns the time left until the circuit allows a trial request.
+func (e *ErrCircuitOpen) Deadline() time.Duration {
+	return time.Until(e.deadline)
+}
+
+// Wait blocks until the circuit allows a trial request or ctx is done.
+func (e *ErrCircuitOpen) Wait(ctx context.Context) error {
+	d := time.Until(e.deadline)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (e *ErrCircuitOpen) Is(target error) bool {
+	return target == ErrServerUnavailable
+}
+
+func IsErrCircuitOpen(err error) (*ErrCircuitOpen, bool) {
+	var e *ErrCircuitOpen
+	ok := errors.As(err, &e)
+	return e, ok
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers with status until it is changed. Every request is
+// counted.
+type flakyServer struct {
+	*httptest.Server
+	status  atomic.Int32
+	hits    atomic.Int32
+	block   chan struct{} // when set, requests wait until it is closed
+	entered chan struct{}
+}
+
+func newFlakyServer(t *testing.T) *flakyServer {
+	s := &flakyServer{}
+	s.status.Store(http.StatusOK)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.hits.Add(1)
+		if s.entered != nil {
+			s.entered <- struct{}{}
+		}
+		if s.block != nil {
+			<-s.block
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(int(s.status.Load()))
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *flakyServer) host() string {
+	u, _ := url.Parse(s.URL)
+	return u.Host
+}
+
+// expire ends the open period of a circuit without waiting.
+func expire(b *CircuitBreaker, host string) {
+	b.mu.Lock()
+	if h, ok := b.hosts[host]; ok {
+		h.until = time.Now().Add(-time.Millisecond)
+	}
+	b.mu.Unlock()
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	ctx := context.Background()
+	srv := newFlakyServer(t)
+	b := NewCircuitBreaker(3, time.Hour)
+	c := NewClient(srv.URL, nil).WithRetryPolicy(nil).WithCircuitBreaker(b)
+	get := func() error { return c.Get(ctx, "/", nil, nil) }
+
+	// failures below the threshold keep the circuit closed, a success
+	// resets the count
+	srv.status.Store(http.StatusServiceUnavailable)
+	get()
+	get()
+	srv.status.Store(http.StatusOK)
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	srv.status.Store(http.StatusInternalServerError)
+	get()
+	get()
+	if s := b.State(srv.host()); s != BreakerClosed {
+		t.Fatalf("state %s after 2 failures, want closed", s)
+	}
+
+	// threshold reached
+	get()
+	if s := b.State(srv.host()); s != BreakerOpen {
+		t.Fatalf("state %s after 3 failures, want open", s)
+	}
+	hits := srv.hits.Load()
+	err := get()
+	e, ok := IsErrCircuitOpen(err)
+	if !ok || e.Host != srv.host() || !errors.Is(err, ErrServerUnavailable) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+	if d := e.Deadline(); d <= 0 || d > time.Hour {
+		t.Errorf("deadline %s", d)
+	}
+	if srv.hits.Load() != hits {
+		t.Errorf("open circuit contacted the server")
+	}
+
+	// a failed trial opens the circuit again
+	expire(b, srv.host())
+	if s := b.State(srv.host()); s != BreakerHalfOpen {
+		t.Fatalf("state %s after timeout, want half-open", s)
+	}
+	get()
+	if s := b.State(srv.host()); s != BreakerOpen {
+		t.Fatalf("state %s after failed trial, want open", s)
+	}
+	if _, ok := IsErrCircuitOpen(get()); !ok {
+		t.Errorf("request passed after failed trial")
+	}
+
+	// a successful trial closes it
+	expire(b, srv.host())
+	srv.status.Store(http.StatusOK)
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.State(srv.host()); s != BreakerClosed {
+		t.Fatalf("state %s after successful trial, want closed", s)
+	}
+
+	// a single failure does not reopen a closed circuit
+	srv.status.Store(http.StatusBadGateway)
+	get()
+	if s := b.State(srv.host()); s != BreakerClosed {
+		t.Errorf("state %s after one failure, want closed", s)
+	}
+
+	b.Reset(srv.host())
+	if s := b.State(srv.host()); s != BreakerClosed {
+		t.Errorf("state %s after reset", s)
+	}
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	ctx := context.Background()
+	srv := newFlakyServer(t)
+	b := NewCircuitBreaker(1, time.Hour)
+	c := NewClient(srv.URL, nil).WithRetryPolicy(nil).WithCircuitBreaker(b)
+
+	srv.status.Store(http.StatusServiceUnavailable)
+	c.Get(ctx, "/", nil, nil)
+	if s := b.State(srv.host()); s != BreakerOpen {
+		t.Fatalf("state %s, want open", s)
+	}
+	expire(b, srv.host())
+
+	// hold the trial request in the handler
+	srv.status.Store(http.StatusOK)
+	srv.block = make(chan struct{})
+	srv.entered = make(chan struct{}, 1)
+	trial := make(chan error, 1)
+	go func() { trial <- c.Get(ctx, "/", nil, nil) }()
+	<-srv.entered
+
+	for i := 0; i < 3; i++ {
+		if _, ok := IsErrCircuitOpen(c.Get(ctx, "/", nil, nil)); !ok {
+			t.Fatalf("second request passed while trial is running")
+		}
+	}
+	if n := srv.hits.Load(); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+	close(srv.block)
+	if err := <-trial; err != nil {
+		t.Fatal(err)
+	}
+	if s := b.State(srv.host()); s != BreakerClosed {
+		t.Errorf("state %s after trial, want closed", s)
+	}
+}
+
+func TestCircuitBreakerIgnores(t *testing.T) {
+	srv := newFlakyServer(t)
+	b := NewCircuitBreaker(2, time.Hour)
+	c := NewClient(srv.URL, nil).WithRetryPolicy(nil).WithCircuitBreaker(b)
+
+	// client errors are the caller's fault
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests} {
+		srv.status.Store(int32(status))
+		for i := 0; i < 3; i++ {
+			c.Get(context.Background(), "/", nil, nil)
+		}
+		if s := b.State(srv.host()); s != BreakerClosed {
+			t.Fatalf("state %s after %d responses, want closed", s, status)
+		}
+	}
+
+	// requests canceled by the caller are not counted as failures
+	srv.status.Store(http.StatusServiceUnavailable)
+	srv.block = make(chan struct{})
+	defer close(srv.block)
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := c.Get(ctx, "/", nil, nil)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("got %v, want deadline exceeded", err)
+		}
+	}
+	if s := b.State(srv.host()); s != BreakerClosed {
+		t.Errorf("state %s after canceled requests, want closed", s)
+	}
+}
+
+func TestClassifyBreaker(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	netErr := &url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   breakerOutcome
+	}{
+		{"ok", context.Background(), http.StatusOK, nil, breakerSuccess},
+		{"not_modified", context.Background(), http.StatusNotModified, nil, breakerSuccess},
+		{"bad_request", context.Background(), http.StatusBadRequest, nil, breakerSuccess},
+		{"not_found", context.Background(), http.StatusNotFound, nil, breakerSuccess},
+		{"rate_limited", context.Background(), http.StatusTooManyRequests, nil, breakerSuccess},
+		{"server_error", context.Background(), http.StatusInternalServerError, nil, breakerFailure},
+		{"unavailable", context.Background(), http.StatusServiceUnavailable, nil, breakerFailure},
+		{"net_error", context.Background(), 0, netErr, breakerFailure},
+		{"other_error", context.Background(), 0, errors.New("x"), breakerSuccess},
+		{"canceled", canceled, 0, netErr, breakerIgnore},
+		{"canceled_5xx", canceled, http.StatusBadGateway, nil, breakerIgnore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://x", nil)
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status}
+			}
+			if got := classifyBreaker(req, resp, tt.err); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrCircuitOpenWait(t *testing.T) {
+	// deadline passed
+	e := &ErrCircuitOpen{Host: "x", deadline: time.Now().Add(-time.Second)}
+	if err := e.Wait(context.Background()); err != nil {
+		t.Errorf("expired: %v", err)
+	}
+
+	// waits for the deadline
+	e = &ErrCircuitOpen{Host: "x", deadline: time.Now().Add(20 * time.Millisecond)}
+	start := time.Now()
+	if err := e.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Errorf("returned after %s", d)
+	}
+
+	// ctx ends first
+	e = &ErrCircuitOpen{Host: "x", deadline: time.Now().Add(time.Hour)}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := e.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,500 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"blockwatch.cc/tzpro-go/internal/util"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// ResponseCache is a storage backend for raw API replies.
+type ResponseCache interface {
+	Get(key string) (*CacheEntry, bool)
+	Add(key string, e *CacheEntry)
+	Remove(key string)
+}
+
+// CacheEntry is a cached API reply.
+type CacheEntry struct {
+	Body    []byte    `json:"body"`
+	ETag    string    `json:"etag,omitempty"`
+	Expires time.Time `json:"expires,omitempty"`
+	Final   bool      `json:"final,omitempty"` // finalized data, never expires
+}
+
+// Fresh returns true when the entry can be used without asking the server.
+func (e *CacheEntry) Fresh() bool {
+	return e.Final || time.Now().Before(e.Expires)
+}
+
+var (
+	_ ResponseCache = (*MemoryCache)(nil)
+	_ ResponseCache = (*DiskCache)(nil)
+)
+
+// MemoryCache keeps the most recently used replies in memory.
+type MemoryCache struct {
+	cache *lru.Cache[string, *CacheEntry]
+}
+
+// NewMemoryCache creates an in-memory cache holding up to size replies.
+func NewMemoryCache(size int) *MemoryCache {
+	if size < 1 {
+		size = DefaultCacheSize
+	}
+	cache, _ := lru.New[string, *CacheEntry](size)
+	return &MemoryCache{cache: cache}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	return c.cache.Get(key)
+}
+
+func (c *MemoryCache) Add(key string, e *CacheEntry) {
+	c.cache.Add(key, e)
+}
+
+func (c *MemoryCache) Remove(key string) {
+	c.cache.Remove(key)
+}
+
+// DiskCache stores replies as files below a directory. Files are written
+// atomically, so the cache survives restarts and crashes and may be shared
+// between processes. The total size is capped at DefaultDiskCacheSize
+// unless set otherwise with WithMaxSize.
+type DiskCache struct {
+	dir     string
+	maxAge  time.Duration
+	maxSize int64
+	mu      sync.Mutex
+	size    int64 // bytes in cache files, -1 until scanned
+}
+
+var DefaultDiskCacheSize int64 = 1 << 30
+
+// NewDiskCache creates a file-backed cache in dir.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{
+		dir:     dir,
+		maxSize: DefaultDiskCacheSize,
+		size:    -1,
+	}, nil
+}
+
+// WithMaxAge drops entries written more than d ago, including finalized
+// data. Zero keeps entries until they are evicted for size.
+func (c *DiskCache) WithMaxAge(d time.Duration) *DiskCache {
+	c.maxAge = d
+	return c
+}
+
+// WithMaxSize caps the total size of cache files at n bytes. The oldest
+// files are removed first when the cache grows beyond the cap. Zero
+// disables the limit.
+func (c *DiskCache) WithMaxSize(n int64) *DiskCache {
+	c.maxSize = n
+	return c
+}
+
+func (c *DiskCache) path(key string) string {
+	h := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(h[:])
+	return filepath.Join(c.dir, name[:2], name+".json")
+}
+
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	name := c.path(key)
+	if c.maxAge > 0 {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return nil, false
+		}
+		if time.Since(fi.ModTime()) > c.maxAge {
+			c.Remove(key)
+			return nil, false
+		}
+	}
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+	e := &CacheEntry{}
+	if err := json.Unmarshal(buf, e); err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+func (c *DiskCache) Add(key string, e *CacheEntry) {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	name := c.path(key)
+	var old int64
+	if fi, err := os.Stat(name); err == nil {
+		old = fi.Size()
+	}
+	if err := util.WriteFileAtomic(name, buf); err != nil {
+		return
+	}
+	c.grow(int64(len(buf)) - old)
+}
+
+func (c *DiskCache) Remove(key string) {
+	name := c.path(key)
+	fi, err := os.Stat(name)
+	if err != nil {
+		return
+	}
+	if os.Remove(name) == nil {
+		c.grow(-fi.Size())
+	}
+}
+
+// grow tracks the cache size and evicts the oldest files once it exceeds
+// the limit. Eviction goes down to 90% of the limit, so it does not run
+// again on the next write.
+func (c *DiskCache) grow(n int64) {
+	if c.maxSize <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size < 0 {
+		// first write, other processes may have filled the cache
+		c.prune(c.maxSize)
+		return
+	}
+	c.size += n
+	if c.size > c.maxSize {
+		c.prune(c.maxSize / 10 * 9)
+	}
+}
+
+// prune scans the cache directory and removes the oldest files until the
+// total size is at most limit.
+func (c *DiskCache) prune(limit int64) {
+	type file struct {
+		name string
+		size int64
+		mod  time.Time
+	}
+	var (
+		files []file
+		size  int64
+	)
+	filepath.WalkDir(c.dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, ".json") {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, file{name, fi.Size(), fi.ModTime()})
+		size += fi.Size()
+		return nil
+	})
+	if size > limit {
+		sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+		for _, f := range files {
+			if size <= limit {
+				break
+			}
+			if os.Remove(f.name) == nil {
+				size -= f.size
+			}
+		}
+	}
+	c.size = size
+}
+
+var (
+	DefaultFinalizedRefresh = 30 * time.Second
+)
+
+// responseCache decides which replies are cached and for how long. Replies
+// that refer to finalized blocks never change and are kept forever, all
+// other replies follow the server's Cache-Control and ETag headers.
+type responseCache struct {
+	store     ResponseCache
+	finalized int64 // finalized chain height
+	refreshed int64 // unix nano of last status update
+	refresh   int32
+}
+
+// WithResponseCache enables caching of GET replies in store. Pass nil to
+// disable caching.
+func (c *Client) WithResponseCache(store ResponseCache) *Client {
+	return c.update(func(cfg *config) {
+		if store == nil {
+			cfg.rcache = nil
+		} else {
+			cfg.rcache = &responseCache{store: store}
+		}
+	})
+}
+
+func (c *Client) ResponseCache() ResponseCache {
+	rc := c.config().rcache
+	if rc == nil {
+		return nil
+	}
+	return rc.store
+}
+
+// SetFinalized tells the response cache about the current finalized
+// height. The client learns it on its own from explorer status replies,
+// use this to feed it from another source like a block follower.
+func (c *Client) SetFinalized(height int64) {
+	if rc := c.config().rcache; rc != nil {
+		rc.setFinalized(height)
+	}
+}
+
+// Finalized returns the finalized height known to the response cache.
+func (c *Client) Finalized() int64 {
+	rc := c.config().rcache
+	if rc == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&rc.finalized)
+}
+
+func (rc *responseCache) setFinalized(height int64) {
+	atomic.StoreInt64(&rc.refreshed, time.Now().UnixNano())
+	for {
+		old := atomic.LoadInt64(&rc.finalized)
+		if height <= old || atomic.CompareAndSwapInt64(&rc.finalized, old, height) {
+			return
+		}
+	}
+}
+
+// wrap returns a handler that serves GET requests from the cache when
+// possible and stores replies otherwise. Expired entries with an ETag are
+// revalidated. Cache hits pass through all middleware like any other reply.
+func (rc *responseCache) wrap(c *Client, cfg *config, next Handler) Handler {
+	return func(req *http.Request, result any) *Response {
+		if req.Method != http.MethodGet || !canShare(result) {
+			return next(req, result)
+		}
+		start := time.Now()
+		key := requestKey(req)
+		e, ok := rc.store.Get(key)
+		if o := callOptionsFrom(req.Context()); o != nil && o.noCache {
+			ok = false
+		}
+		if ok && e.Fresh() {
+			r := decodeCached(e, result)
+			r.Latency = time.Since(start)
+			return r
+		}
+		if ok && e.ETag != "" {
+			req.Header.Set("If-None-Match", e.ETag)
+		}
+
+		var raw json.RawMessage
+		resp := next(req, &raw)
+		if resp.Err != nil {
+			return resp
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusNotModified && ok:
+			// keep the cached body, but pick up new expiry headers
+			if ne := rc.entry(c, cfg, req, resp.Header, e.Body); ne != nil {
+				rc.store.Add(key, ne)
+			}
+			r := decodeCached(e, result)
+			r.Header = resp.Header
+			r.Latency = resp.Latency
+			return r
+		case resp.StatusCode == http.StatusOK:
+			if isStatusPath(req.URL.Path) {
+				var s endpointStatus
+				if s.UnmarshalJSON(raw) == nil && s.Finalized > 0 {
+					rc.setFinalized(s.Finalized)
+				}
+			}
+			if ne := rc.entry(c, cfg, req, resp.Header, raw); ne != nil {
+				rc.store.Add(key, ne)
+			} else if ok {
+				rc.store.Remove(key)
+			}
+		}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, result); err != nil {
+				resp.Err = fmt.Errorf("unmarshaling reply: %w", err)
+			}
+		}
+		return resp
+	}
+}
+
+func decodeCached(e *CacheEntry, result any) *Response {
+	resp := &Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+	}
+	if err := json.Unmarshal(e.Body, result); err != nil {
+		resp.Err = fmt.Errorf("unmarshaling cached reply: %w", err)
+	}
+	return resp
+}
+
+// entry builds a cache entry for a reply or returns nil when the reply
+// must not be cached.
+func (rc *responseCache) entry(c *Client, cfg *config, req *http.Request, h http.Header, body []byte) *CacheEntry {
+	maxAge, noStore := parseCacheControl(h.Get("Cache-Control"))
+	if noStore || len(body) == 0 {
+		return nil
+	}
+	e := &CacheEntry{
+		Body: append([]byte(nil), body...),
+		ETag: h.Get("ETag"),
+	}
+	if height, ok := finalityHeight(req.URL.Path, body); ok {
+		if height <= rc.finalizedHeight(c, cfg) {
+			e.Final = true
+			return e
+		}
+	}
+	if maxAge > 0 {
+		e.Expires = time.Now().Add(maxAge)
+	}
+	if e.Expires.IsZero() && e.ETag == "" {
+		return nil
+	}
+	return e
+}
+
+// finalizedHeight returns the known finalized height. Once the value is
+// older than DefaultFinalizedRefresh the explorer status is loaded in the
+// background, so callers never wait for it. Until the first status arrives
+// replies are cached according to their headers only.
+func (rc *responseCache) finalizedHeight(c *Client, cfg *config) int64 {
+	now := time.Now().UnixNano()
+	if now-atomic.LoadInt64(&rc.refreshed) > int64(DefaultFinalizedRefresh) && atomic.CompareAndSwapInt32(&rc.refresh, 0, 1) {
+		atomic.StoreInt64(&rc.refreshed, now)
+		go rc.refreshFinalized(c, cfg)
+	}
+	return atomic.LoadInt64(&rc.finalized)
+}
+
+func (rc *responseCache) refreshFinalized(c *Client, cfg *config) {
+	defer atomic.StoreInt32(&rc.refresh, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultFinalizedRefresh)
+	defer cancel()
+	ctx = WithCallOptions(ctx, CallNoCache())
+	var s endpointStatus
+	err := c.callAsync(ctx, cfg, http.MethodGet, "/explorer/status", nil, nil, &s, false).Receive(ctx)
+	switch {
+	case err != nil:
+		cfg.log.Debugf("cache: loading finalized height: %v", err)
+	case s.Finalized > 0:
+		rc.setFinalized(s.Finalized)
+	}
+}
+
+// finalityHeight returns the block height an immutable explorer reply
+// refers to. Only block and operation lookups qualify, head and list
+// queries may change at any time.
+func finalityHeight(path string, body []byte) (int64, bool) {
+	fields := strings.Split(strings.Trim(path, "/"), "/")
+	n := len(fields)
+	var (
+		id   string
+		kind string
+	)
+	switch {
+	case n >= 3 && fields[n-3] == "explorer" && (fields[n-2] == "block" || fields[n-2] == "op"):
+		kind, id = fields[n-2], fields[n-1]
+	case n >= 4 && fields[n-4] == "explorer" && fields[n-3] == "block" && fields[n-1] == "operations":
+		kind, id = fields[n-3], fields[n-2]
+	default:
+		return 0, false
+	}
+	if id == "" || id == "head" {
+		return 0, false
+	}
+
+	// block lookups by height are final even when the block has no operations
+	var height int64
+	if kind == "block" {
+		height, _ = strconv.ParseInt(id, 10, 64)
+	}
+
+	type heightOnly struct {
+		Height int64 `json:"height"`
+	}
+	var list []heightOnly
+	switch body[0] {
+	case '{':
+		var v heightOnly
+		if json.Unmarshal(body, &v) != nil {
+			return 0, false
+		}
+		list = append(list, v)
+	case '[':
+		if json.Unmarshal(body, &list) != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	for _, v := range list {
+		if v.Height <= 0 {
+			return 0, false
+		}
+		if v.Height > height {
+			height = v.Height
+		}
+	}
+	return height, height > 0
+}
+
+func isStatusPath(path string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(path, "/"), "/explorer/status")
+}
+
+// parseCacheControl returns the max-age and whether the reply must not be
+// stored. no-cache is treated as max-age=0 so the reply is revalidated.
+func parseCacheControl(s string) (time.Duration, bool) {
+	var maxAge time.Duration
+	for _, v := range strings.Split(s, ",") {
+		v = strings.ToLower(strings.TrimSpace(v))
+		switch {
+		case v == "no-store":
+			return 0, true
+		case v == "no-cache":
+			return 0, false
+		case strings.HasPrefix(v, "max-age="):
+			if n, err := strconv.Atoi(strings.TrimPrefix(v, "max-age=")); err == nil && n > 0 {
+				maxAge = time.Duration(n) * time.Second
+			}
+		}
+	}
+	return maxAge, false
+}
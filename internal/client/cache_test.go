@@ -0,0 +1,304 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// explorerServer serves block lookups and the explorer status and counts
+// requests per path.
+type explorerServer struct {
+	*httptest.Server
+	finalized int64
+	header    http.Header // extra reply headers
+	mu        sync.Mutex
+	hits      map[string]int
+	etagHits  int
+}
+
+func newExplorerServer(t *testing.T, finalized int64, header http.Header) *explorerServer {
+	s := &explorerServer{finalized: finalized, header: header, hits: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		etag := s.header.Get("ETag")
+		if etag != "" && r.Header.Get("If-None-Match") == etag {
+			s.etagHits++
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		s.mu.Unlock()
+		for n, v := range s.header {
+			w.Header()[n] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/explorer/status" {
+			fmt.Fprintf(w, `{"status":"synced","blocks":%d,"finalized":%d,"indexed":%d}`, s.finalized+2, s.finalized, s.finalized+2)
+			return
+		}
+		var height int64
+		fmt.Sscanf(r.URL.Path, "/explorer/block/%d", &height)
+		fmt.Fprintf(w, `{"height":%d}`, height)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *explorerServer) Hits(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+type heightReply struct {
+	Height int64 `json:"height"`
+}
+
+func getHeight(t *testing.T, c *Client, path string) int64 {
+	t.Helper()
+	var r heightReply
+	if err := c.Get(context.Background(), path, nil, &r); err != nil {
+		t.Fatal(err)
+	}
+	return r.Height
+}
+
+func TestResponseCacheFinality(t *testing.T) {
+	srv := newExplorerServer(t, 100, nil)
+	c := NewClient(srv.URL, nil).WithResponseCache(NewMemoryCache(100))
+	c.SetFinalized(100)
+
+	tests := []struct {
+		path     string
+		wantHits int
+	}{
+		{"/explorer/block/90", 1},  // below finalized
+		{"/explorer/block/100", 1}, // at finalized
+		{"/explorer/block/101", 3}, // not final, no cache headers
+		{"/explorer/block/head", 3},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 3; i++ {
+			getHeight(t, c, tt.path)
+		}
+		if n := srv.Hits(tt.path); n != tt.wantHits {
+			t.Errorf("%s: server saw %d requests, want %d", tt.path, n, tt.wantHits)
+		}
+	}
+}
+
+func TestResponseCacheFinalityRefresh(t *testing.T) {
+	defer func(d time.Duration) { DefaultFinalizedRefresh = d }(DefaultFinalizedRefresh)
+	DefaultFinalizedRefresh = time.Hour
+
+	srv := newExplorerServer(t, 100, nil)
+	c := NewClient(srv.URL, nil).WithResponseCache(NewMemoryCache(100))
+
+	// the first cacheable reply triggers a background status refresh and
+	// is not held up by it
+	getHeight(t, c, "/explorer/block/90")
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Finalized() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("finalized height was not refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if c.Finalized() != 100 {
+		t.Fatalf("finalized %d, want 100", c.Finalized())
+	}
+	for i := 0; i < 3; i++ {
+		getHeight(t, c, "/explorer/block/90")
+	}
+	if n := srv.Hits("/explorer/block/90"); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+	if n := srv.Hits("/explorer/status"); n != 1 {
+		t.Errorf("status loaded %d times, want 1", n)
+	}
+}
+
+func TestResponseCacheHeaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		wantHits  int
+		wantEtags int
+	}{
+		{
+			name:     "max_age",
+			header:   http.Header{"Cache-Control": {"max-age=60"}},
+			wantHits: 1,
+		},
+		{
+			name:      "etag",
+			header:    http.Header{"Etag": {`"v1"`}},
+			wantHits:  3,
+			wantEtags: 2,
+		},
+		{
+			name:      "etag_no_cache",
+			header:    http.Header{"Etag": {`"v1"`}, "Cache-Control": {"no-cache"}},
+			wantHits:  3,
+			wantEtags: 2,
+		},
+		{
+			name:     "no_store",
+			header:   http.Header{"Etag": {`"v1"`}, "Cache-Control": {"max-age=60, no-store"}},
+			wantHits: 3,
+		},
+		{
+			name:     "none",
+			wantHits: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newExplorerServer(t, 0, tt.header)
+			c := NewClient(srv.URL, nil).WithResponseCache(NewMemoryCache(100))
+			for i := 0; i < 3; i++ {
+				if h := getHeight(t, c, "/explorer/block/head"); h != 0 {
+					t.Fatalf("got height %d", h)
+				}
+				if h := getHeight(t, c, "/explorer/block/7/x"); h != 7 {
+					t.Fatalf("got height %d", h)
+				}
+			}
+			if n := srv.Hits("/explorer/block/head"); n != tt.wantHits {
+				t.Errorf("server saw %d requests, want %d", n, tt.wantHits)
+			}
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			if srv.etagHits != 2*tt.wantEtags {
+				t.Errorf("server answered %d revalidations, want %d", srv.etagHits, 2*tt.wantEtags)
+			}
+		})
+	}
+}
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	srv := newExplorerServer(t, 0, http.Header{"Cache-Control": {"max-age=60"}})
+	var (
+		mu       sync.Mutex
+		statuses []int
+	)
+	c := NewClient(srv.URL, nil).
+		WithResponseCache(NewMemoryCache(100)).
+		Use(func(next Handler) Handler {
+			return func(req *http.Request, result any) *Response {
+				resp := next(req, result)
+				// the background finality refresh passes here too
+				if req.URL.Path == "/explorer/block/5" {
+					mu.Lock()
+					statuses = append(statuses, resp.StatusCode)
+					mu.Unlock()
+				}
+				return resp
+			}
+		})
+	getHeight(t, c, "/explorer/block/5")
+	getHeight(t, c, "/explorer/block/5")
+
+	// bypass the cache for a single call
+	ctx := WithCallOptions(context.Background(), CallNoCache())
+	var r heightReply
+	if err := c.Get(ctx, "/explorer/block/5", nil, &r); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Hits("/explorer/block/5"); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(statuses) != "[200 200 200]" {
+		t.Errorf("middleware saw %v, want every call", statuses)
+	}
+}
+
+func TestResponseCacheNoCacheRefresh(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"height":%d}`, hits)
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil).WithResponseCache(NewMemoryCache(100))
+
+	var got []int64
+	for _, noCache := range []bool{false, false, true, false} {
+		ctx := context.Background()
+		if noCache {
+			ctx = WithCallOptions(ctx, CallNoCache())
+		}
+		var r heightReply
+		if err := c.Get(ctx, "/explorer/block/head", nil, &r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.Height)
+	}
+	// the fresh reply replaces the cached one for later calls
+	if fmt.Sprint(got) != "[1 1 2 2]" || hits != 2 {
+		t.Errorf("replies %v after %d requests, want [1 1 2 2] after 2", got, hits)
+	}
+}
+
+func TestDiskCacheLimits(t *testing.T) {
+	entry := &CacheEntry{Body: []byte(strings.Repeat("x", 1000)), Final: true}
+	dir := t.TempDir()
+	dc, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc.WithMaxSize(10_000)
+
+	// writes beyond the cap evict the oldest files
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprint("key", i)
+		dc.Add(key, entry)
+		os.Chtimes(dc.path(key), old, old.Add(time.Duration(i)*time.Second))
+	}
+	var size int64
+	filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	if size > 10_000 {
+		t.Errorf("cache holds %d bytes, limit is 10000", size)
+	}
+	if _, ok := dc.Get("key0"); ok {
+		t.Errorf("oldest entry survived eviction")
+	}
+	if _, ok := dc.Get("key19"); !ok {
+		t.Errorf("newest entry was evicted")
+	}
+
+	// entries older than max age are dropped on read
+	dc.WithMaxAge(time.Minute)
+	if _, ok := dc.Get("key19"); ok {
+		t.Errorf("expired entry was returned")
+	}
+	if _, err := os.Stat(dc.path("key19")); !os.IsNotExist(err) {
+		t.Errorf("expired entry was not removed")
+	}
+	dc.Add("fresh", entry)
+	if e, ok := dc.Get("fresh"); !ok || string(e.Body) != string(entry.Body) {
+		t.Errorf("fresh entry not found")
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"blockwatch.cc/tzgo/tezos"
@@ -25,15 +27,38 @@ var (
 	DefaultCacheSize = 2048
 )
 
+// Client is safe for concurrent use. Settings live in an immutable config
+// snapshot which With* methods replace atomically, so reconfiguring a client
+// never races with requests in flight. Use Clone to derive clients with
+// different settings that share connections, caches and quotas.
 type Client struct {
+	conf    atomic.Pointer[config]
+	quota   *quotaTracker
+	flights *flightGroup
+	stats   *cacheStats
+}
+
+// config holds client settings. A config is never modified after it has
+// been published, updates copy it first.
+type config struct {
 	transport  *http.Client
 	log        log.Logger
 	base       Query
-	cache      *lru.TwoQueueCache[tezos.Address, any]
+	cache      ScriptCache
 	headers    http.Header
 	userAgent  string
 	numRetries int
 	retryDelay time.Duration
+	retry      RetryPolicy
+	numLimits  int
+	maxWait    time.Duration
+	limiter    *Limiter
+	middleware []Middleware
+	handler    Handler
+	pool       *EndpointPool
+	breaker    *CircuitBreaker
+	coalesce   bool
+	rcache     *responseCache
 }
 
 func NewClient(url string, httpClient *http.Client) *Client {
@@ -59,6 +84,11 @@ func NewClient(url string, httpClient *http.Client) *Client {
 	}
 	cache, _ := lru.New2Q[tezos.Address, any](sz)
 	c := &Client{
+		quota:   &quotaTracker{},
+		flights: &flightGroup{},
+		stats:   &cacheStats{},
+	}
+	cfg := &config{
 		transport:  httpClient,
 		log:        log.Disabled,
 		base:       params,
@@ -68,64 +98,227 @@ func NewClient(url string, httpClient *http.Client) *Client {
 		numRetries: 0,
 		retryDelay: 0,
 	}
+	cfg.handler = c.chain(cfg)
+	c.conf.Store(cfg)
 	return c
 }
 
+// Clone returns a new client with the same settings. Both clients share the
+// HTTP connection pool, script and response caches, limiter, endpoint pool,
+// circuit breaker and quota tracking. Settings changed on the clone with
+// With* methods do not affect the original client.
+func (c *Client) Clone() *Client {
+	n := &Client{
+		quota:   c.quota,
+		flights: c.flights,
+		stats:   c.stats,
+	}
+	cfg := c.config().clone()
+	cfg.handler = n.chain(cfg)
+	n.conf.Store(cfg)
+	return n
+}
+
+// config returns the current settings. The result must not be modified.
+func (c *Client) config() *config {
+	return c.conf.Load()
+}
+
+// update applies fn to a copy of the current settings and publishes the
+// copy. fn may run more than once when updates race.
+func (c *Client) update(fn func(cfg *config)) *Client {
+	for {
+		old := c.conf.Load()
+		cfg := old.clone()
+		fn(cfg)
+		cfg.handler = c.chain(cfg)
+		if c.conf.CompareAndSwap(old, cfg) {
+			return c
+		}
+	}
+}
+
+func (cfg *config) clone() *config {
+	n := *cfg
+	n.headers = cfg.headers.Clone()
+	n.middleware = append([]Middleware(nil), cfg.middleware...)
+	return &n
+}
+
+// DefaultHeaders returns a copy of the headers sent with every request.
 func (c *Client) DefaultHeaders() http.Header {
-	return c.headers
+	return c.config().headers.Clone()
 }
 
 func (c *Client) WithHeader(key, value string) *Client {
-	c.headers.Set(key, value)
-	return c
+	return c.update(func(cfg *config) {
+		cfg.headers.Set(key, value)
+	})
 }
 
 func (c *Client) WithUserAgent(s string) *Client {
-	c.userAgent = s
-	return c
+	return c.update(func(cfg *config) {
+		cfg.userAgent = s
+	})
 }
 
 func (c *Client) WithApiKey(s string) *Client {
-	if s != "" {
-		c.headers.Set("X-Api-Key", s)
-	} else {
-		c.headers.Del("X-Api-Key")
-	}
-	return c
+	return c.update(func(cfg *config) {
+		if s != "" {
+			cfg.headers.Set("X-Api-Key", s)
+		} else {
+			cfg.headers.Del("X-Api-Key")
+		}
+	})
 }
 
 func (c *Client) WithUrl(url string) *Client {
-	if params, err := ParseQuery(url); err == nil {
-		c.base = params
+	params, err := ParseQuery(url)
+	if err != nil {
+		return c
 	}
-	return c
+	return c.update(func(cfg *config) {
+		cfg.base = params
+	})
 }
 
+// WithTLS sets the TLS configuration. This requires a new connection pool,
+// so the client stops sharing connections with clones made before. Custom
+// transports that are not an *http.Transport are left untouched.
 func (c *Client) WithTLS(tc *tls.Config) *Client {
-	c.transport.Transport.(*http.Transport).TLSClientConfig = tc
-	return c
+	return c.update(func(cfg *config) {
+		tr, ok := cfg.transport.Transport.(*http.Transport)
+		if !ok {
+			cfg.log.Warnf("cannot set TLS config on transport %T", cfg.transport.Transport)
+			return
+		}
+		tr = tr.Clone()
+		tr.TLSClientConfig = tc
+		hc := *cfg.transport
+		hc.Transport = tr
+		cfg.transport = &hc
+	})
 }
 
+// WithTimeout sets the total request timeout. Connections remain shared
+// with clones unless the timeout exceeds the transport's response header
+// timeout, which then requires a new connection pool.
 func (c *Client) WithTimeout(d time.Duration) *Client {
-	if tr, ok := c.transport.Transport.(*http.Transport); ok {
-		tr.ResponseHeaderTimeout = d
-	}
-	c.transport.Timeout = d
-	return c
+	return c.update(func(cfg *config) {
+		hc := *cfg.transport
+		hc.Timeout = d
+		if tr, ok := hc.Transport.(*http.Transport); ok && tr.ResponseHeaderTimeout > 0 && d > tr.ResponseHeaderTimeout {
+			tr = tr.Clone()
+			tr.ResponseHeaderTimeout = d
+			hc.Transport = tr
+		}
+		cfg.transport = &hc
+	})
 }
 
 func (c *Client) WithRetry(num int, delay time.Duration) *Client {
-	c.numRetries = num
-	if num < 0 {
-		c.numRetries = int(^uint(0)>>1) - 1 // max int - 1
-	}
-	c.retryDelay = delay
-	return c
+	return c.update(func(cfg *config) {
+		cfg.numRetries = num
+		if num < 0 {
+			cfg.numRetries = int(^uint(0)>>1) - 1 // max int - 1
+		}
+		cfg.retryDelay = delay
+		cfg.retry = constantRetry{num: cfg.numRetries, delay: delay}
+	})
+}
+
+// WithRetryPolicy replaces the retry policy set by WithRetry, e.g. with
+// a BackoffPolicy. Pass nil to disable retries.
+func (c *Client) WithRetryPolicy(p RetryPolicy) *Client {
+	return c.update(func(cfg *config) {
+		cfg.retry = p
+	})
+}
+
+func (c *Client) RetryPolicy() RetryPolicy {
+	return c.config().retry
+}
+
+// WithRateLimitRetry makes the client wait and retry up to num times when
+// the API responds with 429 Too Many Requests. The wait time is read from
+// the Retry-After or X-RateLimit-Reset headers. Responses that demand a
+// longer wait than maxWait (when > 0) are returned as ErrRateLimited.
+func (c *Client) WithRateLimitRetry(num int, maxWait time.Duration) *Client {
+	return c.update(func(cfg *config) {
+		cfg.numLimits = num
+		if num < 0 {
+			cfg.numLimits = int(^uint(0)>>1) - 1 // max int - 1
+		}
+		cfg.maxWait = maxWait
+	})
+}
+
+// WithRateLimit throttles all requests sent through this client to rps
+// requests per second with bursts of up to burst requests.
+func (c *Client) WithRateLimit(rps float64, burst int) *Client {
+	return c.update(func(cfg *config) {
+		var n int
+		if cfg.limiter != nil {
+			n = cfg.limiter.MaxInFlight()
+		}
+		cfg.limiter = NewLimiter(rps, burst, n)
+	})
+}
+
+// WithMaxConcurrency caps the number of requests in flight at the same time.
+func (c *Client) WithMaxConcurrency(n int) *Client {
+	return c.update(func(cfg *config) {
+		var (
+			rps   float64
+			burst int
+		)
+		if cfg.limiter != nil {
+			rps, burst = cfg.limiter.Rate()
+		}
+		cfg.limiter = NewLimiter(rps, burst, n)
+	})
+}
+
+// WithLimiter installs a custom limiter, e.g. to share one quota among
+// several clients. Pass nil to disable client-side limits.
+func (c *Client) WithLimiter(l *Limiter) *Client {
+	return c.update(func(cfg *config) {
+		cfg.limiter = l
+	})
+}
+
+func (c *Client) Limiter() *Limiter {
+	return c.config().limiter
+}
+
+// WithCircuitBreaker makes the client fail fast while a host keeps
+// failing. Pass nil to disable.
+func (c *Client) WithCircuitBreaker(b *CircuitBreaker) *Client {
+	return c.update(func(cfg *config) {
+		cfg.breaker = b
+	})
+}
+
+func (c *Client) CircuitBreaker() *CircuitBreaker {
+	return c.config().breaker
+}
+
+// WithCoalescing makes concurrent identical GET requests share a single
+// API call. Lookups that use GetShared are always coalesced.
+func (c *Client) WithCoalescing(enable bool) *Client {
+	return c.update(func(cfg *config) {
+		cfg.coalesce = enable
+	})
 }
 
 func (c *Client) WithLogger(log log.Logger) *Client {
-	c.log = log
-	return c
+	return c.update(func(cfg *config) {
+		cfg.log = log
+	})
+}
+
+func (c *Client) Logger() log.Logger {
+	return c.config().log
 }
 
 func (c *Client) WithCacheSize(sz int) *Client {
@@ -133,28 +326,50 @@ func (c *Client) WithCacheSize(sz int) *Client {
 		sz = 2
 	}
 	cache, _ := lru.New2Q[tezos.Address, any](sz)
-	c.cache = cache
-	return c
+	return c.update(func(cfg *config) {
+		cfg.cache = cache
+	})
+}
+
+// UseScriptCache replaces the script cache, e.g. with a persistent
+// implementation that survives restarts.
+func (c *Client) UseScriptCache(cache ScriptCache) {
+	c.update(func(cfg *config) {
+		cfg.cache = cache
+	})
+}
+
+// ScriptCache returns the current script cache.
+func (c *Client) ScriptCache() ScriptCache {
+	return c.config().cache
 }
 
-func (c *Client) UseScriptCache(cache *lru.TwoQueueCache[tezos.Address, any]) {
-	c.cache = cache
+// CacheStats returns script cache hit and miss counters.
+func (c *Client) CacheStats() CacheStats {
+	return c.stats.get()
 }
 
-func (c Client) Retries() int {
-	return c.numRetries
+func (c *Client) Retries() int {
+	return c.config().numRetries
 }
 
-func (c Client) RetryDelay() time.Duration {
-	return c.retryDelay
+func (c *Client) RetryDelay() time.Duration {
+	return c.config().retryDelay
+}
+
+// RateLimit returns the API quota reported by the most recent response.
+func (c *Client) RateLimit() RateLimit {
+	return c.quota.get()
 }
 
 func (c *Client) CacheGet(key tezos.Address) (any, bool) {
-	return c.cache.Get(key)
+	val, ok := c.config().cache.Get(key)
+	c.stats.count(ok)
+	return val, ok
 }
 
 func (c *Client) CacheAdd(key tezos.Address, val any) {
-	c.cache.Add(key, val)
+	c.config().cache.Add(key, val)
 }
 
 func (c *Client) Get(ctx context.Context, path string, headers http.Header, result any) error {
@@ -174,43 +389,77 @@ func (c *Client) Delete(ctx context.Context, path string, headers http.Header) e
 }
 
 func (c *Client) Async(ctx context.Context, path string, headers http.Header, result any) FutureResult {
-	return c.callAsync(ctx, http.MethodGet, path, headers, nil, result)
+	cfg := c.config()
+	return c.callAsync(ctx, cfg, http.MethodGet, path, headers, nil, result, cfg.coalesce)
+}
+
+// GetShared is like Get, but concurrent calls for the same URL share a
+// single request to the API. Each caller decodes the shared reply into its
+// own result value. Use it for popular, immutable lookups like scripts.
+func (c *Client) GetShared(ctx context.Context, path string, headers http.Header, result any) error {
+	return c.callAsync(ctx, c.config(), http.MethodGet, path, headers, nil, result, true).Receive(ctx)
 }
 
 func (c *Client) call(ctx context.Context, method, path string, headers http.Header, data, result any) error {
-	return c.callAsync(ctx, method, path, headers, data, result).Receive(ctx)
+	cfg := c.config()
+	return c.callAsync(ctx, cfg, method, path, headers, data, result, cfg.coalesce && method == http.MethodGet).Receive(ctx)
 }
 
-func (c *Client) callAsync(ctx context.Context, method, path string, headers http.Header, data, result any) FutureResult {
+// callAsync sends a request with the settings in cfg. Callers load the
+// config once, so that a call never mixes settings of concurrent updates.
+func (c *Client) callAsync(ctx context.Context, cfg *config, method, path string, headers http.Header, data, result any, shared bool) FutureResult {
 	if !strings.HasPrefix(path, "http") {
-		path = c.base.WithPath(path).Url()
+		path = cfg.base.WithPath(path).Url()
+	}
+
+	opts := callOptionsFrom(ctx)
+	if opts != nil && opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
 	}
 
-	req, err := c.newRequest(ctx, method, path, headers, data, result)
+	req, err := c.newRequest(ctx, cfg, method, path, headers, data, result)
 	if err != nil {
 		return newFutureError(err)
 	}
 
-	responseChan := make(chan *response, 1)
-	c.handleRequest(&request{
-		httpRequest:     req,
-		responseVal:     result,
-		responseHeaders: headers,
-		responseChan:    responseChan,
-	})
+	var resp *Response
+	if shared && canShare(result) {
+		resp = c.dispatchShared(ctx, cfg, req, result)
+	} else {
+		resp = c.dispatch(ctx, cfg, req, result)
+	}
+	if headers != nil && resp.Header != nil {
+		mergeHeaders(headers, resp.Header, nil)
+	}
 
+	responseChan := make(chan *response, 1)
+	responseChan <- &response{
+		status:  resp.StatusCode,
+		headers: resp.Header,
+		result:  resp.Body,
+		request: (&request{httpRequest: req}).String(),
+		err:     resp.Err,
+	}
 	return responseChan
 }
 
-func (c *Client) newRequest(ctx context.Context, method, path string, headers http.Header, data, result any) (*http.Request, error) {
+// dispatch sends the request through the handler chain. The limiter is
+// applied to each attempt further down in do.
+func (c *Client) dispatch(ctx context.Context, cfg *config, req *http.Request, result any) *Response {
+	return cfg.handler(req, result)
+}
+
+func (c *Client) newRequest(ctx context.Context, cfg *config, method, path string, headers http.Header, data, result any) (*http.Request, error) {
 	// prepare headers
 	if headers == nil {
 		headers = make(http.Header)
 	}
-	headers.Set("User-Agent", c.userAgent)
+	headers.Set("User-Agent", cfg.userAgent)
 
 	// copy default headers
-	for n, v := range c.headers {
+	for n, v := range cfg.headers {
 		for _, vv := range v {
 			headers.Add(n, vv)
 		}
@@ -234,7 +483,7 @@ func (c *Client) newRequest(ctx context.Context, method, path string, headers ht
 	}
 
 	// create http request
-	c.log.Debugf("%s %s", method, path)
+	cfg.log.Debugf("%s %s", method, path)
 	req, err := http.NewRequest(method, path, body)
 	if err != nil {
 		return nil, err
@@ -248,6 +497,11 @@ func (c *Client) newRequest(ctx context.Context, method, path string, headers ht
 		headers.Del("Content-Type")
 	}
 
+	// apply per-call overrides
+	if opts := callOptionsFrom(ctx); opts != nil {
+		opts.apply(headers)
+	}
+
 	// add all passed in headers
 	for n, v := range headers {
 		if strings.ToLower(n) == "host" {
@@ -265,47 +519,40 @@ func (c *Client) newRequest(ctx context.Context, method, path string, headers ht
 // handleRequest executes the passed HTTP request, reading the
 // result, unmarshalling it, and delivering the unmarshalled result to the
 // provided response channel.
-func (c *Client) handleRequest(req *request) {
+func (c *Client) handleRequest(cfg *config, req *request) {
 	// only dump content-type application/json
-	c.log.Trace(log.NewClosure(func() string {
+	cfg.log.Trace(log.NewClosure(func() string {
 		r, _ := httputil.DumpRequestOut(req.httpRequest, req.httpRequest.Header.Get("Content-Type") == "application/json")
 		return string(r)
 	}))
 
-	var (
-		resp *http.Response
-		err  error
-	)
-	for retries := c.numRetries + 1; retries > 0; retries-- {
-		resp, err = c.transport.Do(req.httpRequest)
-		if err == nil {
-			break
-		}
-		if !isNetError(err) {
-			break
-		}
-		select {
-		case <-req.httpRequest.Context().Done():
-			req.responseChan <- &response{
-				err:     req.httpRequest.Context().Err(),
-				request: req.String(),
-			}
-			return
-		case <-time.After(c.retryDelay):
-			// continue
-		}
-	}
+	resp, err := c.send(cfg, req)
 	if err != nil {
 		req.responseChan <- &response{err: err, request: req.String()}
 		return
 	}
 	defer resp.Body.Close()
 
-	c.log.Tracef("response: %s", log.NewClosure(func() string {
+	cfg.log.Tracef("response: %s", log.NewClosure(func() string {
 		s, _ := httputil.DumpResponse(resp, isTextResponse(resp))
 		return string(s)
 	}))
 
+	// decode directly from the response body when the response value
+	// supports streaming, this avoids buffering large responses
+	if resp.StatusCode == http.StatusOK && req.responseVal != nil {
+		if sd, ok := req.responseVal.(StreamDecoder); ok {
+			err := sd.DecodeStream(resp.Body)
+			req.responseChan <- &response{
+				status:  resp.StatusCode,
+				request: req.String(),
+				headers: mergeHeaders(req.responseHeaders, resp.Header, resp.Trailer),
+				err:     err,
+			}
+			return
+		}
+	}
+
 	// process as stream when response interface is an io.Writer
 	if resp.StatusCode == http.StatusOK && req.responseVal != nil {
 		if stream, ok := req.responseVal.(io.Writer); ok {
@@ -347,10 +594,9 @@ func (c *Client) handleRequest(req *request) {
 	// error codes as details which we cannot parse here; some other APIs
 	// even send 5xx error codes to signal non-error situations)
 	if resp.StatusCode >= 400 {
-		if resp.StatusCode == 429 {
-			// TODO: read rate limit header
-			wait := 5 * time.Second
-			err = newRateLimitError(resp, wait)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := parseRetryAfter(resp.Header, DefaultRateLimitWait)
+			err = newRateLimitErrorWithBody(resp, respBytes, wait)
 		} else {
 			err = newHttpErrorWithBody(resp, respBytes)
 		}
@@ -390,3 +636,121 @@ func (c *Client) handleRequest(req *request) {
 		err:     err,
 	}
 }
+
+// send executes the HTTP request. It retries on network errors and, when
+// enabled, waits and retries when the API signals a rate limit.
+func (c *Client) send(cfg *config, req *request) (*http.Response, error) {
+	ctx := req.httpRequest.Context()
+	for limits := cfg.numLimits; ; limits-- {
+		resp, err := c.do(cfg, req)
+		if err != nil {
+			return nil, err
+		}
+		c.quota.update(resp.Header)
+		if resp.StatusCode != http.StatusTooManyRequests || limits <= 0 {
+			return resp, nil
+		}
+		wait := parseRetryAfter(resp.Header, DefaultRateLimitWait)
+		if cfg.maxWait > 0 && wait > cfg.maxWait {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := rewindBody(req.httpRequest); err != nil {
+			return nil, err
+		}
+		cfg.log.Debugf("rate limited, retrying %s in %s", req, wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+			// continue
+		}
+	}
+}
+
+// do executes the HTTP request and retries failed attempts as decided by
+// the retry policy. Every attempt waits for the limiter and holds its slot
+// until the response body is closed.
+func (c *Client) do(cfg *config, req *request) (*http.Response, error) {
+	ctx := req.httpRequest.Context()
+	retry := cfg.retryPolicy(ctx)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		var done func(breakerOutcome)
+		if cfg.breaker != nil {
+			var err error
+			done, err = cfg.breaker.allow(req.httpRequest.URL.Host)
+			if err != nil {
+				return nil, err
+			}
+		}
+		release := func() {}
+		if cfg.limiter != nil {
+			var err error
+			release, err = cfg.limiter.Acquire(ctx)
+			if err != nil {
+				if done != nil {
+					done(breakerIgnore)
+				}
+				return nil, err
+			}
+		}
+		resp, err := cfg.transport.Do(req.httpRequest)
+		if done != nil {
+			done(classifyBreaker(req.httpRequest, resp, err))
+		}
+		if err != nil {
+			release()
+		} else {
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		}
+		if retry == nil || (err == nil && resp.StatusCode < 400) {
+			return resp, err
+		}
+		delay, ok := retry.Retry(req.httpRequest, resp, err, attempt, time.Since(start))
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := rewindBody(req.httpRequest); err != nil {
+			return nil, err
+		}
+		cfg.log.Debugf("retrying %s in %s (attempt %d)", req, delay, attempt)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+			// continue
+		}
+	}
+}
+
+// releaseBody returns a limiter slot when the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// rewindBody resets the request body so the request can be sent again.
+func rewindBody(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWithUpdatesClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`"` + r.Header.Get("X-Api-Key") + `"`))
+	}))
+	defer srv.Close()
+
+	base := NewClient(srv.URL, nil)
+	base.WithApiKey("base")
+	if base.WithRateLimit(10, 1) != base || base.Limiter() == nil {
+		t.Errorf("WithRateLimit did not change the receiver")
+	}
+	base.WithRateLimit(0, 0)
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			tenant := base.Clone().WithApiKey(key)
+			for i := 0; i < 10; i++ {
+				var got string
+				if err := tenant.Get(context.Background(), "/", nil, &got); err != nil {
+					t.Error(err)
+					return
+				}
+				if got != key {
+					t.Errorf("tenant %s sent key %q", key, got)
+					return
+				}
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	var got string
+	if err := base.Get(context.Background(), "/", nil, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "base" {
+		t.Errorf("base client sent key %q after cloning tenants", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// flightGroup deduplicates concurrent identical requests.
+type flightGroup struct {
+	mu sync.Mutex
+	m  map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	resp    *Response
+	raw     json.RawMessage
+	waiters int // callers waiting for the shared reply
+}
+
+// canShare returns true when result can be decoded from a shared reply.
+// Streaming consumers read the body themselves and cannot share it.
+func canShare(result any) bool {
+	switch result.(type) {
+	case nil, io.Writer, StreamDecoder:
+		return false
+	default:
+		return true
+	}
+}
+
+// requestKey identifies requests that would return the same reply. All
+// request headers are part of the key, so calls with different per-call
+// headers or API keys never share a reply.
+func requestKey(req *http.Request) string {
+	names := make([]string, 0, len(req.Header))
+	for n := range req.Header {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(0)
+	b.WriteString(req.URL.String())
+	for _, n := range names {
+		b.WriteByte(0)
+		b.WriteString(n)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(req.Header[n], ","))
+	}
+	return b.String()
+}
+
+// flightKey identifies requests that can share a reply in flight. Calls
+// that bypass the response cache only share with other such calls.
+func flightKey(req *http.Request) string {
+	key := requestKey(req)
+	if o := callOptionsFrom(req.Context()); o != nil && o.noCache {
+		key += "\x00no-cache"
+	}
+	return key
+}
+
+// dispatchShared sends req unless an identical request is already in
+// flight, in which case it waits for and decodes the shared reply.
+func (c *Client) dispatchShared(ctx context.Context, cfg *config, req *http.Request, result any) *Response {
+	key := flightKey(req)
+	g := c.flights
+	g.mu.Lock()
+	if g.m == nil {
+		g.m = make(map[string]*flight)
+	}
+	f, ok := g.m[key]
+	if !ok {
+		f = &flight{done: make(chan struct{})}
+		g.m[key] = f
+	} else {
+		f.waiters++
+	}
+	g.mu.Unlock()
+
+	if !ok {
+		// leader: fetch the raw reply on behalf of all waiters
+		f.resp = c.dispatch(ctx, cfg, req, &f.raw)
+		g.mu.Lock()
+		delete(g.m, key)
+		g.mu.Unlock()
+		close(f.done)
+	} else {
+		select {
+		case <-ctx.Done():
+			return &Response{Err: ctx.Err()}
+		case <-f.done:
+		}
+		// the leader was canceled, but we are not: try on our own
+		if isContextError(f.resp.Err) && ctx.Err() == nil {
+			return c.dispatchShared(ctx, cfg, req, result)
+		}
+	}
+
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	if resp.Err == nil && len(f.raw) > 0 {
+		if err := json.Unmarshal(f.raw, result); err != nil {
+			resp.Err = fmt.Errorf("unmarshaling reply: %w", err)
+		}
+	}
+	return &resp
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// holdServer blocks every request until release is closed and replies
+// with the request's hit number.
+type holdServer struct {
+	*httptest.Server
+	hits    atomic.Int32
+	status  int
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newHoldServer(t *testing.T, status int) *holdServer {
+	s := &holdServer{
+		status:  status,
+		entered: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.hits.Add(1)
+		s.entered <- struct{}{}
+		select {
+		case <-s.release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(s.status)
+		fmt.Fprintf(w, `{"hit":%d,"key":%q}`, n, r.Header.Get("X-Api-Key"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+type hitReply struct {
+	Hit int    `json:"hit"`
+	Key string `json:"key"`
+}
+
+// waitWaiters waits until n callers joined a flight.
+func waitWaiters(t *testing.T, c *Client, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.flights.mu.Lock()
+		var got int
+		for _, f := range c.flights.m {
+			got += f.waiters
+		}
+		c.flights.mu.Unlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d waiters, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type callResult struct {
+	reply hitReply
+	err   error
+}
+
+func goGet(ctx context.Context, c *Client) chan callResult {
+	ch := make(chan callResult, 1)
+	go func() {
+		var r callResult
+		r.err = c.Get(ctx, "/", nil, &r.reply)
+		ch <- r
+	}()
+	return ch
+}
+
+func TestCoalesceShared(t *testing.T) {
+	srv := newHoldServer(t, http.StatusOK)
+	c := NewClient(srv.URL, nil).WithCoalescing(true)
+	ctx := context.Background()
+
+	leader := goGet(ctx, c)
+	<-srv.entered
+	var waiters []chan callResult
+	for i := 0; i < 4; i++ {
+		waiters = append(waiters, goGet(ctx, c))
+	}
+	waitWaiters(t, c, 4)
+	close(srv.release)
+
+	for i, ch := range append(waiters, leader) {
+		r := <-ch
+		if r.err != nil || r.reply.Hit != 1 {
+			t.Errorf("caller %d: %+v %v", i, r.reply, r.err)
+		}
+	}
+	if n := srv.hits.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+
+	// without coalescing every call is sent
+	var wg sync.WaitGroup
+	c = c.WithCoalescing(false)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Get(ctx, "/", nil, nil)
+		}()
+	}
+	wg.Wait()
+	if n := srv.hits.Load(); n != 4 {
+		t.Errorf("server got %d requests, want 4", n)
+	}
+}
+
+func TestCoalesceCallOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func(context.Context) context.Context
+	}{
+		{"api_key", func(ctx context.Context) context.Context { return WithCallOptions(ctx, CallApiKey("other")) }},
+		{"header", func(ctx context.Context) context.Context { return WithCallOptions(ctx, CallHeader("X-Tenant", "b")) }},
+		{"no_cache", func(ctx context.Context) context.Context { return WithCallOptions(ctx, CallNoCache()) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newHoldServer(t, http.StatusOK)
+			c := NewClient(srv.URL, nil).WithApiKey("base").WithCoalescing(true)
+
+			leader := goGet(context.Background(), c)
+			<-srv.entered
+			other := goGet(tt.ctx(context.Background()), c)
+			select {
+			case <-srv.entered:
+			case <-time.After(5 * time.Second):
+				t.Fatal("call with different options joined the flight")
+			}
+			close(srv.release)
+
+			a, b := <-leader, <-other
+			if a.err != nil || b.err != nil {
+				t.Fatal(a.err, b.err)
+			}
+			if a.reply.Hit == b.reply.Hit || a.reply.Key != "base" {
+				t.Errorf("replies %+v and %+v", a.reply, b.reply)
+			}
+		})
+	}
+}
+
+func TestFlightKey(t *testing.T) {
+	newReq := func(ctx context.Context, url string, hdr ...string) *http.Request {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		for i := 0; i < len(hdr); i += 2 {
+			req.Header.Add(hdr[i], hdr[i+1])
+		}
+		return req
+	}
+	ctx := context.Background()
+	base := flightKey(newReq(ctx, "http://x/a", "X-Api-Key", "k", "Accept", "application/json"))
+	if key := flightKey(newReq(ctx, "http://x/a", "Accept", "application/json", "X-Api-Key", "k")); key != base {
+		t.Errorf("header order changed the key")
+	}
+	for name, req := range map[string]*http.Request{
+		"url":      newReq(ctx, "http://x/b", "X-Api-Key", "k", "Accept", "application/json"),
+		"api_key":  newReq(ctx, "http://x/a", "X-Api-Key", "j", "Accept", "application/json"),
+		"accept":   newReq(ctx, "http://x/a", "X-Api-Key", "k", "Accept", "text/csv"),
+		"extra":    newReq(ctx, "http://x/a", "X-Api-Key", "k", "Accept", "application/json", "X-Tenant", "a"),
+		"multi":    newReq(ctx, "http://x/a", "X-Api-Key", "k", "Accept", "application/json", "Accept", "text/csv"),
+		"no_cache": newReq(WithCallOptions(ctx, CallNoCache()), "http://x/a", "X-Api-Key", "k", "Accept", "application/json"),
+	} {
+		if flightKey(req) == base {
+			t.Errorf("%s: requests share a key", name)
+		}
+	}
+}
+
+func TestCoalesceLeaderCancel(t *testing.T) {
+	srv := newHoldServer(t, http.StatusOK)
+	c := NewClient(srv.URL, nil).WithRetryPolicy(nil).WithCoalescing(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := goGet(ctx, c)
+	<-srv.entered
+	waiter := goGet(context.Background(), c)
+	waitWaiters(t, c, 1)
+
+	// the waiter sends its own request when the leader gives up
+	cancel()
+	if r := <-leader; !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("leader: got %v, want context.Canceled", r.err)
+	}
+	<-srv.entered
+	close(srv.release)
+	r := <-waiter
+	if r.err != nil || r.reply.Hit != 2 {
+		t.Errorf("waiter: %+v %v", r.reply, r.err)
+	}
+
+	// a canceled waiter returns without affecting the leader
+	leader = goGet(context.Background(), c)
+	<-srv.entered
+	ctx, cancel = context.WithCancel(context.Background())
+	waiter = goGet(ctx, c)
+	cancel()
+	if r := <-waiter; !errors.Is(r.err, context.Canceled) {
+		t.Errorf("waiter: got %v, want context.Canceled", r.err)
+	}
+	if r := <-leader; r.err != nil || r.reply.Hit != 3 {
+		t.Errorf("leader: %+v %v", r.reply, r.err)
+	}
+}
+
+func TestCoalesceErrorFanOut(t *testing.T) {
+	srv := newHoldServer(t, http.StatusServiceUnavailable)
+	c := NewClient(srv.URL, nil).WithRetryPolicy(nil).WithCoalescing(true)
+	ctx := context.Background()
+
+	leader := goGet(ctx, c)
+	<-srv.entered
+	var waiters []chan callResult
+	for i := 0; i < 3; i++ {
+		waiters = append(waiters, goGet(ctx, c))
+	}
+	waitWaiters(t, c, 3)
+	close(srv.release)
+
+	for i, ch := range append(waiters, leader) {
+		r := <-ch
+		e, ok := IsErrHttp(r.err)
+		if !errors.Is(r.err, ErrServerUnavailable) || !ok || e.StatusCode() != http.StatusServiceUnavailable {
+			t.Errorf("caller %d: got %v, want HTTP 503", i, r.err)
+		}
+	}
+	if n := srv.hits.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
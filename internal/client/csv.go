@@ -0,0 +1,169 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"blockwatch.cc/tzpro-go/internal/util"
+)
+
+// CSVDecoder maps columns of a CSV header onto struct fields using the same
+// json and tzpro struct tags as the JSON table decoder.
+type CSVDecoder struct {
+	fields []*FieldInfo // nil for ignored columns
+}
+
+// DecodeCSV reads a CSV table with header line from r and calls fn for every
+// row as soon as it is decoded.
+func DecodeCSV[T any](r io.Reader, fn func(T) error) error {
+	etyp := reflect.TypeOf((*T)(nil)).Elem()
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	switch {
+	case err == io.EOF:
+		// empty response body
+		return nil
+	case err != nil:
+		return err
+	}
+	dec, err := buildCSVDecoder(etyp, header)
+	if err != nil {
+		return err
+	}
+
+	for row := 0; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		elem, ev := newElem(etyp)
+		if err := dec.decode(record, ev); err != nil {
+			return withRow(err, row)
+		}
+		if err := fn(elem.Elem().Interface().(T)); err != nil {
+			return err
+		}
+	}
+}
+
+func buildCSVDecoder(typ reflect.Type, header []string) (*CSVDecoder, error) {
+	tinfo, err := getReflectTypeInfo(typ, tagName)
+	if err != nil {
+		return nil, err
+	}
+	d := &CSVDecoder{
+		fields: make([]*FieldInfo, len(header)),
+	}
+	for i, name := range header {
+		fi, ok := tinfo.Find(name)
+		if !ok {
+			return nil, fmt.Errorf("decode: missing type field %q", name)
+		}
+		// skip ignore fields
+		if fi.ContainsFlag(fieldFlagIgnore) {
+			continue
+		}
+		d.fields[i] = &fi
+	}
+	return d, nil
+}
+
+func (d *CSVDecoder) decode(record []string, dst reflect.Value) error {
+	if len(record) != len(d.fields) {
+		return fmt.Errorf("decode: csv record has %d columns, expected %d", len(record), len(d.fields))
+	}
+
+	// allocate and deref ptr types
+	dst = derefValue(dst)
+
+	for i, fi := range d.fields {
+		if fi == nil || record[i] == "" {
+			continue
+		}
+		f := derefValue(fi.Value(dst))
+		if err := decodeCSVValue(fi, record[i], f); err != nil {
+			return &DecodeError{Column: fi.Alias, Err: err}
+		}
+	}
+	return nil
+}
+
+func decodeCSVValue(fi *FieldInfo, s string, f reflect.Value) error {
+	switch {
+	case fi.ContainsFlag(fieldFlagHex):
+		// hex: decode hex to bin, then call binary unmarshaler
+		buf, err := hex.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		return unmarshalBinary(f, buf)
+	case fi.ContainsFlag(fieldFlagTime):
+		// time: decode int or time string
+		var tm util.Time
+		if err := tm.UnmarshalText([]byte(s)); err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(tm.Time()))
+		return nil
+	case fi.ContainsFlag(fieldFlagBool):
+		// bool: decode int or string
+		var b util.Bool
+		if err := b.UnmarshalText([]byte(s)); err != nil {
+			return err
+		}
+		f.SetBool(b.Bool())
+		return nil
+	}
+
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(v)
+	default:
+		// embedded JSON (arrays, objects) or raw values like hex encoded
+		// parameters that are sent as unquoted strings in CSV
+		buf := []byte(s)
+		switch s[0] {
+		case '{', '[', '"':
+		default:
+			buf = []byte(strconv.Quote(s))
+		}
+		return json.Unmarshal(buf, f.Addr().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeCSV(t *testing.T) {
+	format := func(r eachRow) string {
+		return fmt.Sprintf("%d %s %t %x %s", r.RowId, r.Time.UTC().Format(time.RFC3339), r.Active, []byte(r.Data), r.Name)
+	}
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr string
+		wantRow int
+		wantCol string
+	}{
+		{
+			name: "mapping",
+			body: "row_id,time,active,data,name\n" +
+				"1,2024-01-02T03:04:05Z,true,cafe,a\n" +
+				"2,1704164645000,1,00ff,\"b,c\"\n" +
+				"3,,false,,\n",
+			want: []string{
+				"1 2024-01-02T03:04:05Z true cafe a",
+				"2 2024-01-02T03:04:05Z true 00ff b,c",
+				"3 0001-01-01T00:00:00Z false  ",
+			},
+		},
+		{
+			name: "column_order",
+			body: "name,row_id\nx,7\n",
+			want: []string{"7 0001-01-01T00:00:00Z false  x"},
+		},
+		{
+			name: "header_only",
+			body: "row_id,name\n",
+		},
+		{
+			name: "empty",
+			body: "",
+		},
+		{
+			name:    "unknown_column",
+			body:    "row_id,unknown\n1,2\n",
+			wantErr: `missing type field "unknown"`,
+		},
+		{
+			name:    "bad_hex",
+			body:    "row_id,data\n1,cafe\n2,xyz\n",
+			want:    []string{"1 0001-01-01T00:00:00Z false cafe "},
+			wantErr: "invalid byte",
+			wantRow: 1,
+			wantCol: "data",
+		},
+		{
+			name:    "bad_bool",
+			body:    "row_id,active\n1,maybe\n",
+			wantErr: "maybe",
+			wantRow: 0,
+			wantCol: "active",
+		},
+		{
+			name:    "short_record",
+			body:    "row_id,name\n1,a\n2\n",
+			want:    []string{"1 0001-01-01T00:00:00Z false  a"},
+			wantErr: "wrong number of fields",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := DecodeCSV(strings.NewReader(tt.body), func(r eachRow) error {
+				got = append(got, format(r))
+				return nil
+			})
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got rows %q, want %q", got, tt.want)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantCol != "" {
+				var derr *DecodeError
+				if !errors.As(err, &derr) || derr.Row != tt.wantRow || derr.Column != tt.wantCol {
+					t.Errorf("got %#v, want row %d column %q", err, tt.wantRow, tt.wantCol)
+				}
+			}
+		})
+	}
+}
+
+func TestTableQueryCSV(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/tables/op.csv") || r.Header.Get("Accept") != "text/csv" {
+			t.Errorf("unexpected request %s with Accept %q", r.URL, r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("row_id,height\n1,101\n2,102\n"))
+	}))
+	defer srv.Close()
+
+	res, err := NewTableQuery[pageRow](NewClient(srv.URL, nil), "op").
+		WithFormat("csv").
+		Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Len() != 2 || res.Cursor() != 2 || res.Rows()[0].Height != 101 {
+		t.Errorf("got rows %+v", res.Rows())
+	}
+}
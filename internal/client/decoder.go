@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"io"
 
 	"bytes"
 	"encoding/json"
@@ -21,6 +22,7 @@ type Decoder struct {
 	id    uint32
 	idx   []int // we only handle flat structs because thats what the SDK uses
 	flags []int
+	names []string
 }
 
 func DecodeSlice(buf []byte, fields []string, val any) error {
@@ -35,7 +37,7 @@ func DecodeSlice(buf []byte, fields []string, val any) error {
 	}
 
 	etyp := v.Type().Elem()
-	dec, err := buildDecoder(etyp, fields)
+	dec, err := buildDecoder(etyp, fields, false)
 	if err != nil {
 		return err
 	}
@@ -45,28 +47,85 @@ func DecodeSlice(buf []byte, fields []string, val any) error {
 	if err != nil {
 		return err
 	}
+	return decodeRows(jdec, etyp, dec, func(elem reflect.Value) error {
+		v.Set(reflect.Append(v, elem))
+		return nil
+	})
+}
+
+// DecodeEach decodes a JSON array of column arrays from r and calls fn for
+// every row as soon as it is decoded. Unlike DecodeSlice it never holds more
+// than a single row in memory, so it is safe to use on very large responses.
+func DecodeEach[T any](r io.Reader, fields []string, fn func(T) error) error {
+	etyp := reflect.TypeOf((*T)(nil)).Elem()
+	dec, err := buildDecoder(etyp, fields, false)
+	if err != nil {
+		return err
+	}
+
+	jdec := json.NewDecoder(r)
+	tok, err := jdec.Token()
+	switch {
+	case err == io.EOF:
+		// empty response body
+		return nil
+	case err != nil:
+		return err
+	case tok == nil:
+		// null
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return fmt.Errorf("decode: %s expected JSON array", etyp)
+	}
+	return decodeRows(jdec, etyp, dec, func(elem reflect.Value) error {
+		return fn(elem.Interface().(T))
+	})
+}
 
-	// walk outer json array [
-	for jdec.More() {
-		elem := reflect.New(etyp)
-		ev := elem
-		if elem.Elem().Kind() == reflect.Ptr {
-			ev.Elem().Set(reflect.New(elem.Elem().Type().Elem()))
-			ev = reflect.Indirect(elem)
+// decodeRows walks the outer JSON array after its opening bracket was
+// consumed and calls fn for each decoded element.
+func decodeRows(jdec *json.Decoder, etyp reflect.Type, dec *Decoder, fn func(reflect.Value) error) error {
+	for row := 0; jdec.More(); row++ {
+		elem, ev := newElem(etyp)
+		if err := dec.decode(jdec, ev); err != nil {
+			return withRow(err, row)
 		}
-		err = dec.decode(jdec, ev)
-		if err != nil {
+		if err := fn(elem.Elem()); err != nil {
 			return err
 		}
-		v.Set(reflect.Append(v, elem.Elem()))
 	}
 
 	// consume outer json arry closing bracket ]
-	_, err = jdec.Token()
+	_, err := jdec.Token()
 	return err
 }
 
+// newElem allocates a new slice element of type etyp and returns a pointer
+// to it together with the value to decode into. For pointer element types
+// the pointed-to struct is allocated as well.
+func newElem(etyp reflect.Type) (elem, ev reflect.Value) {
+	elem = reflect.New(etyp)
+	ev = elem
+	if elem.Elem().Kind() == reflect.Ptr {
+		ev.Elem().Set(reflect.New(elem.Elem().Type().Elem()))
+		ev = reflect.Indirect(elem)
+	}
+	return
+}
+
 func Decode(buf []byte, fields []string, val any) error {
+	return decodeStruct(buf, fields, val, false)
+}
+
+// DecodeLenient is like Decode, but skips columns without matching struct
+// field instead of failing. Use it for streams like ZMQ that may send
+// columns the SDK does not know about.
+func DecodeLenient(buf []byte, fields []string, val any) error {
+	return decodeStruct(buf, fields, val, true)
+}
+
+func decodeStruct(buf []byte, fields []string, val any, lenient bool) error {
 	// val must be pointer to struct
 	v := reflect.ValueOf(val)
 	if v.Kind() != reflect.Ptr {
@@ -76,13 +135,25 @@ func Decode(buf []byte, fields []string, val any) error {
 	if v.Kind() != reflect.Struct {
 		return fmt.Errorf("decode: non slice type %T for Decode", val)
 	}
-	dec, err := buildDecoder(v.Type(), fields)
+	dec, err := buildDecoder(v.Type(), fields, lenient)
 	if err != nil {
 		return err
 	}
 
 	jdec := json.NewDecoder(bytes.NewReader(buf))
-	return dec.decode(jdec, v)
+	return withRow(dec.decode(jdec, v), 0)
+}
+
+// withRow adds the row index to decode errors.
+func withRow(err error, row int) error {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*DecodeError); ok {
+		e.Row = row
+		return e
+	}
+	return &DecodeError{Row: row, Err: err}
 }
 
 func (d *Decoder) decode(dec *json.Decoder, dst reflect.Value) error {
@@ -97,49 +168,74 @@ func (d *Decoder) decode(dec *json.Decoder, dst reflect.Value) error {
 
 	// while the array contains values
 	for i, pos := range d.idx {
-		// custom pre-decoding
-		f := derefValue(dst.Field(pos))
-		switch {
-		case d.flags[i]&fieldFlagHex > 0:
-			// hex: decode hex to bin, then call binary unmarshaler
-			var s string
-			if err := dec.Decode(&s); err != nil {
-				return err
-			}
-			if len(s) > 0 {
-				buf, err := hex.DecodeString(s)
-				if err != nil {
-					return err
-				}
-				if err := f.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(buf); err != nil {
-					return err
-				}
+		if pos < 0 {
+			// skip columns without struct field
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return &DecodeError{Column: d.names[i], Err: err}
 			}
-		case d.flags[i]&fieldFlagTime > 0:
-			// time: decode int or time string
-			var tm util.Time
-			if err := dec.Decode(&tm); err != nil {
-				return err
-			}
-			f.Set(reflect.ValueOf(tm.Time()))
-		case d.flags[i]&fieldFlagBool > 0:
-			// bool: decode int or string
-			var b util.Bool
-			if err := dec.Decode(&b); err != nil {
+			continue
+		}
+		if err := d.decodeField(dec, derefValue(dst.Field(pos)), d.flags[i]); err != nil {
+			return &DecodeError{Column: d.names[i], Err: err}
+		}
+	}
+
+	// read closing bracket
+	_, err = dec.Token()
+	return err
+}
+
+// decodeField decodes a single column value into f.
+func (d *Decoder) decodeField(dec *json.Decoder, f reflect.Value, flags int) error {
+	// custom pre-decoding
+	switch {
+	case flags&fieldFlagHex > 0:
+		// hex: decode hex to bin, then call binary unmarshaler
+		var s string
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		if len(s) > 0 {
+			buf, err := hex.DecodeString(s)
+			if err != nil {
 				return err
 			}
-			f.Set(reflect.ValueOf(b.Bool()))
-		default:
-			// decode an array value
-			if err := dec.Decode(f.Addr().Interface()); err != nil {
+			if err := unmarshalBinary(f, buf); err != nil {
 				return err
 			}
 		}
+	case flags&fieldFlagTime > 0:
+		// time: decode int or time string
+		var tm util.Time
+		if err := dec.Decode(&tm); err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(tm.Time()))
+	case flags&fieldFlagBool > 0:
+		// bool: decode int or string
+		var b util.Bool
+		if err := dec.Decode(&b); err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(b.Bool()))
+	default:
+		// decode an array value
+		if err := dec.Decode(f.Addr().Interface()); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	// read closing bracket
-	_, err = dec.Token()
-	return err
+// unmarshalBinary passes buf to the binary unmarshaler of f. Hex columns
+// require a field type that implements encoding.BinaryUnmarshaler.
+func unmarshalBinary(f reflect.Value, buf []byte) error {
+	u, ok := f.Addr().Interface().(encoding.BinaryUnmarshaler)
+	if !ok {
+		return fmt.Errorf("hex field type %s does not implement encoding.BinaryUnmarshaler", f.Type())
+	}
+	return u.UnmarshalBinary(buf)
 }
 
 var decoderMap = make(map[uint32]*Decoder)
@@ -154,8 +250,12 @@ func typeHash(n string, s ...string) uint32 {
 	return h.Sum32()
 }
 
-func buildDecoder(typ reflect.Type, fields []string) (*Decoder, error) {
-	key := typeHash(typ.Name(), fields...)
+func buildDecoder(typ reflect.Type, fields []string, lenient bool) (*Decoder, error) {
+	name := typ.String()
+	if lenient {
+		name += "/lenient"
+	}
+	key := typeHash(name, fields...)
 	decoderLock.RLock()
 	d, ok := decoderMap[key]
 	decoderLock.RUnlock()
@@ -173,15 +273,25 @@ func buildDecoder(typ reflect.Type, fields []string) (*Decoder, error) {
 		id:    key,
 		idx:   make([]int, len(fields)),
 		flags: make([]int, len(fields)),
+		names: fields,
 	}
 
 	for i, f := range fields {
 		fi, ok := tinfo.Find(f)
+		if !ok && lenient {
+			// fields excluded from tables may still be sent by streams
+			fi, ok = findJsonField(typ, f)
+		}
 		if !ok {
-			return nil, fmt.Errorf("decode: missing type field %q", f)
+			if !lenient {
+				return nil, fmt.Errorf("decode: missing type field %q", f)
+			}
+			d.idx[i] = -1
+			continue
 		}
 		// skip ignore fields
 		if fi.ContainsFlag(fieldFlagIgnore) {
+			d.idx[i] = -1
 			continue
 		}
 		d.idx[i] = fi.Idx[0] // first index only, no nested structs
@@ -0,0 +1,239 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hexBytes is a binary column sent as hex string.
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalBinary(buf []byte) error {
+	*h = append((*h)[:0], buf...)
+	return nil
+}
+
+type streamRow struct {
+	Height int64    `json:"height"`
+	Data   hexBytes `json:"data"   tzpro:"payload,hex"`
+	Extra  string   `json:"extra"  tzpro:"-"`
+}
+
+func TestDecodeLenient(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []string
+		row     string
+		lenient bool
+		want    streamRow
+		wantErr bool
+	}{
+		{
+			name:   "table",
+			fields: []string{"height", "payload"},
+			row:    `[1,"cafe"]`,
+			want:   streamRow{Height: 1, Data: hexBytes{0xca, 0xfe}},
+		},
+		{
+			name:    "strict/json_name",
+			fields:  []string{"height", "data"},
+			row:     `[1,"cafe"]`,
+			wantErr: true,
+		},
+		{
+			name:    "strict/excluded",
+			fields:  []string{"height", "extra"},
+			row:     `[1,"x"]`,
+			wantErr: true,
+		},
+		{
+			name:    "lenient/json_name",
+			fields:  []string{"height", "data"},
+			row:     `[1,"cafe"]`,
+			lenient: true,
+			want:    streamRow{Height: 1, Data: hexBytes{0xca, 0xfe}},
+		},
+		{
+			name:    "lenient/excluded",
+			fields:  []string{"height", "extra"},
+			row:     `[1,"x"]`,
+			lenient: true,
+			want:    streamRow{Height: 1, Extra: "x"},
+		},
+		{
+			name:    "lenient/unknown",
+			fields:  []string{"height", "unknown", "payload"},
+			row:     `[1,{"a":[1,2]},"cafe"]`,
+			lenient: true,
+			want:    streamRow{Height: 1, Data: hexBytes{0xca, 0xfe}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got streamRow
+				err error
+			)
+			if tt.lenient {
+				err = DecodeLenient([]byte(tt.row), tt.fields, &got)
+			} else {
+				err = Decode([]byte(tt.row), tt.fields, &got)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Height != tt.want.Height || got.Extra != tt.want.Extra || hex.EncodeToString(got.Data) != hex.EncodeToString(tt.want.Data) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type eachRow struct {
+	RowId  uint64    `json:"row_id"`
+	Time   time.Time `json:"time"`
+	Active bool      `json:"active"`
+	Data   hexBytes  `json:"data"   tzpro:",hex"`
+	Name   string    `json:"name"`
+}
+
+func TestDecodeEach(t *testing.T) {
+	fields := []string{"row_id", "time", "active", "data", "name"}
+	tests := []struct {
+		name    string
+		fields  []string
+		body    string
+		stopAt  int // fn fails on this row id
+		want    []string
+		wantErr string
+		wantRow int
+		wantCol string
+	}{
+		{
+			name: "rows",
+			body: `[[1,"2024-01-02T03:04:05Z",true,"cafe","a"],[2,1704164645000,0,"","b"]]`,
+			want: []string{
+				"1 2024-01-02T03:04:05Z true cafe a",
+				"2 2024-01-02T03:04:05Z false  b",
+			},
+		},
+		{
+			name: "empty_body",
+			body: ``,
+		},
+		{
+			name: "null",
+			body: `null`,
+		},
+		{
+			name: "empty_array",
+			body: `[]`,
+		},
+		{
+			name:    "not_array",
+			body:    `{"row_id":1}`,
+			wantErr: "expected JSON array",
+		},
+		{
+			name:    "unknown_column",
+			fields:  []string{"row_id", "unknown"},
+			body:    `[[1,2]]`,
+			wantErr: `missing type field "unknown"`,
+		},
+		{
+			name:    "bad_column",
+			body:    `[[1,"2024-01-02T03:04:05Z",true,"cafe","a"],[2,"2024-01-02T03:04:05Z",true,"xyz","b"]]`,
+			want:    []string{"1 2024-01-02T03:04:05Z true cafe a"},
+			wantErr: "invalid byte",
+			wantRow: 1,
+			wantCol: "data",
+		},
+		{
+			name:    "handler_error",
+			body:    `[[1,0,0,"","a"],[2,0,0,"","b"],[3,0,0,"","c"]]`,
+			stopAt:  2,
+			want:    []string{"1 1970-01-01T00:00:00Z false  a"},
+			wantErr: "stop",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.fields == nil {
+				tt.fields = fields
+			}
+			var got []string
+			err := DecodeEach(strings.NewReader(tt.body), tt.fields, func(r eachRow) error {
+				if int(r.RowId) == tt.stopAt {
+					return errors.New("stop")
+				}
+				got = append(got, fmt.Sprintf("%d %s %t %x %s", r.RowId, r.Time.UTC().Format(time.RFC3339), r.Active, []byte(r.Data), r.Name))
+				return nil
+			})
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got rows %q, want %q", got, tt.want)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantCol != "" {
+				var derr *DecodeError
+				if !errors.As(err, &derr) || derr.Row != tt.wantRow || derr.Column != tt.wantCol {
+					t.Errorf("got %#v, want row %d column %q", err, tt.wantRow, tt.wantCol)
+				}
+			}
+		})
+	}
+}
+
+// badHexRow has a hex column without binary unmarshaler.
+type badHexRow struct {
+	RowId uint64 `json:"row_id"`
+	Data  string `json:"data"   tzpro:",hex"`
+}
+
+func TestDecodeHexType(t *testing.T) {
+	fields := []string{"row_id", "data"}
+	decoders := map[string]func() error{
+		"json": func() error {
+			var rows []badHexRow
+			return DecodeSlice([]byte(`[[1,""],[2,"cafe"]]`), fields, &rows)
+		},
+		"each": func() error {
+			return DecodeEach(strings.NewReader(`[[1,""],[2,"cafe"]]`), fields, func(badHexRow) error { return nil })
+		},
+		"csv": func() error {
+			return DecodeCSV(strings.NewReader("row_id,data\n2,cafe\n"), func(badHexRow) error { return nil })
+		},
+	}
+	for name, decode := range decoders {
+		t.Run(name, func(t *testing.T) {
+			err := decode()
+			var derr *DecodeError
+			if !errors.As(err, &derr) || derr.Column != "data" {
+				t.Fatalf("got %v, want decode error for column data", err)
+			}
+			if !strings.Contains(err.Error(), "does not implement encoding.BinaryUnmarshaler") {
+				t.Errorf("got %v", err)
+			}
+		})
+	}
+}
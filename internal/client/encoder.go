@@ -0,0 +1,117 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// EncodeSlice encodes a slice of structs into the column array format of
+// table responses. It is the inverse of DecodeSlice and mainly useful for
+// fake servers in tests.
+func EncodeSlice(val any, fields []string) ([]byte, error) {
+	v := reflect.Indirect(reflect.ValueOf(val))
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("encode: non slice type %T for EncodeSlice", val)
+	}
+	rows := make([][]json.RawMessage, v.Len())
+	for i := range rows {
+		row, err := EncodeRow(v.Index(i).Interface(), fields)
+		if err != nil {
+			return nil, withRow(err, i)
+		}
+		rows[i] = row
+	}
+	return json.Marshal(rows)
+}
+
+// EncodeRow encodes the given fields of a struct as JSON array values. All
+// fields are encoded when fields is empty.
+func EncodeRow(val any, fields []string) ([]json.RawMessage, error) {
+	v := reflect.Indirect(reflect.ValueOf(val))
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode: non struct type %T for EncodeRow", val)
+	}
+	if !v.CanAddr() {
+		// copy so that marshalers with pointer receivers are found
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(v)
+		v = cp
+	}
+	tinfo, err := getReflectTypeInfo(v.Type(), tagName)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		fields = tinfo.FilteredAliases(fieldFlagIgnore)
+	}
+	row := make([]json.RawMessage, len(fields))
+	for i, name := range fields {
+		fi, ok := tinfo.Find(name)
+		if !ok {
+			return nil, &DecodeError{Column: name, Err: fmt.Errorf("missing type field")}
+		}
+		buf, err := encodeField(v.FieldByIndex(fi.Idx), fi.Flags)
+		if err != nil {
+			return nil, &DecodeError{Column: name, Err: err}
+		}
+		row[i] = buf
+	}
+	return row, nil
+}
+
+// Columns returns the table column names of a struct type in the order
+// EncodeRow uses when no fields are given.
+func Columns(val any) ([]string, error) {
+	tinfo, err := getTypeInfo(val)
+	if err != nil {
+		return nil, err
+	}
+	return tinfo.FilteredAliases(fieldFlagIgnore), nil
+}
+
+// encodeField encodes a single column value the way the API sends it.
+func encodeField(f reflect.Value, flags int) (json.RawMessage, error) {
+	switch {
+	case flags&fieldFlagHex > 0:
+		if f.Kind() == reflect.Ptr && f.IsNil() {
+			return json.RawMessage(`""`), nil
+		}
+		var m encoding.BinaryMarshaler
+		switch {
+		case f.CanAddr() && f.Addr().Type().Implements(binaryMarshalerType):
+			m = f.Addr().Interface().(encoding.BinaryMarshaler)
+		case f.Type().Implements(binaryMarshalerType):
+			m = f.Interface().(encoding.BinaryMarshaler)
+		default:
+			return nil, fmt.Errorf("type %s is not a binary marshaler", f.Type())
+		}
+		buf, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(hex.EncodeToString(buf))
+	case flags&fieldFlagTime > 0:
+		tm := f.Interface().(time.Time)
+		if tm.IsZero() {
+			return json.RawMessage(`0`), nil
+		}
+		return json.RawMessage(strconv.FormatInt(tm.UnixMilli(), 10)), nil
+	case flags&fieldFlagBool > 0:
+		if f.Bool() {
+			return json.RawMessage(`1`), nil
+		}
+		return json.RawMessage(`0`), nil
+	default:
+		return json.Marshal(f.Addr().Interface())
+	}
+}
+
+var binaryMarshalerType = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
@@ -0,0 +1,170 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hexCode is a binary column that can be encoded and decoded.
+type hexCode []byte
+
+func (h hexCode) MarshalBinary() ([]byte, error) {
+	return h, nil
+}
+
+func (h *hexCode) UnmarshalBinary(buf []byte) error {
+	*h = append((*h)[:0], buf...)
+	return nil
+}
+
+type encodeRow struct {
+	RowId  uint64    `json:"row_id"`
+	Time   time.Time `json:"time"`
+	Active bool      `json:"active"`
+	Code   hexCode   `json:"code"   tzpro:",hex"`
+	Ptr    *hexCode  `json:"ptr"    tzpro:",hex"`
+	Name   string    `json:"name"   tzpro:"alias"`
+	Skip   string    `json:"skip"   tzpro:"-"`
+	Amount float64   `json:"amount"`
+}
+
+func TestColumns(t *testing.T) {
+	want := []string{"row_id", "time", "active", "code", "ptr", "alias", "amount"}
+	for _, v := range []any{encodeRow{}, &encodeRow{}} {
+		got, err := Columns(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%T: columns %v, want %v", v, got, want)
+		}
+	}
+	if _, err := Columns(1); err == nil {
+		t.Errorf("expected error for non struct type")
+	}
+}
+
+func TestEncodeRow(t *testing.T) {
+	code := hexCode{0xbe, 0xef}
+	row := encodeRow{
+		RowId:  7,
+		Time:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Active: true,
+		Code:   hexCode{0xca, 0xfe},
+		Ptr:    &code,
+		Name:   "a",
+		Skip:   "x",
+		Amount: 1.5,
+	}
+	tests := []struct {
+		name    string
+		val     any
+		fields  []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "all",
+			val:  row,
+			want: `7 1704164645000 1 "cafe" "beef" "a" 1.5`,
+		},
+		{
+			name: "pointer",
+			val:  &row,
+			want: `7 1704164645000 1 "cafe" "beef" "a" 1.5`,
+		},
+		{
+			name: "zero",
+			val:  encodeRow{},
+			want: `0 0 0 "" "" "" 0`,
+		},
+		{
+			name:   "fields",
+			val:    row,
+			fields: []string{"alias", "row_id"},
+			want:   `"a" 7`,
+		},
+		{
+			name:    "unknown_field",
+			val:     row,
+			fields:  []string{"row_id", "skip"},
+			wantErr: `skip`,
+		},
+		{
+			name:    "non_struct",
+			val:     []encodeRow{row},
+			wantErr: "non struct type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vals, err := EncodeRow(tt.val, tt.fields)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range vals {
+				got = append(got, string(v))
+			}
+			if s := strings.Join(got, " "); s != tt.want {
+				t.Errorf("got %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeSliceRoundTrip(t *testing.T) {
+	code := hexCode{0xbe, 0xef}
+	in := []encodeRow{
+		{RowId: 1, Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Active: true, Code: hexCode{0xca, 0xfe}, Ptr: &code, Name: "a", Amount: 1.5},
+		{RowId: 2, Time: time.UnixMilli(1704164645001).UTC(), Name: "b", Code: hexCode{}},
+	}
+	for _, fields := range [][]string{nil, {"alias", "code", "row_id"}} {
+		buf, err := EncodeSlice(in, fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields == nil {
+			fields, _ = Columns(encodeRow{})
+		}
+		var out []encodeRow
+		if err := DecodeSlice(buf, fields, &out); err != nil {
+			t.Fatalf("decode %s: %v", buf, err)
+		}
+		if len(out) != len(in) {
+			t.Fatalf("got %d rows, want %d", len(out), len(in))
+		}
+		for i := range in {
+			a, b := in[i], out[i]
+			if a.RowId != b.RowId || a.Name != b.Name || hex.EncodeToString(a.Code) != hex.EncodeToString(b.Code) {
+				t.Errorf("row %d: got %+v, want %+v", i, b, a)
+			}
+			if len(fields) > 3 && (!a.Time.Equal(b.Time) || a.Active != b.Active || a.Amount != b.Amount) {
+				t.Errorf("row %d: got %+v, want %+v", i, b, a)
+			}
+		}
+	}
+
+	// errors name the row and column
+	_, err := EncodeSlice([]encodeRow{{}, {}}, []string{"unknown"})
+	var e *DecodeError
+	if !errors.As(err, &e) || e.Column != "unknown" || e.Row != 0 {
+		t.Errorf("got %#v, want DecodeError for row 0 column unknown", err)
+	}
+	if _, err := EncodeSlice(encodeRow{}, nil); err == nil {
+		t.Errorf("expected error for non slice type")
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalanceMode selects how requests are spread across healthy endpoints.
+type BalanceMode byte
+
+const (
+	BalanceRoundRobin BalanceMode = iota
+	BalanceLatency
+)
+
+var (
+	DefaultMaxFailures   = 3
+	DefaultProbeInterval = 10 * time.Second
+	DefaultMaxLag        = int64(2)
+)
+
+// EndpointPool spreads requests across several API servers. Endpoints that
+// fail repeatedly with network or 5xx errors are ejected and probed in the
+// background until they recover. Endpoints whose index lags behind the
+// chain head are avoided while better ones exist.
+type EndpointPool struct {
+	Mode          BalanceMode
+	MaxFailures   int           // consecutive failures before ejection
+	ProbeInterval time.Duration // status probe interval
+	MaxLag        int64         // tolerated indexed blocks behind the chain head
+
+	endpoints []*Endpoint
+	next      uint32
+	lastProbe int64 // unix nano
+	probing   int32
+}
+
+// Endpoint tracks health of a single API server.
+type Endpoint struct {
+	base     Query
+	mu       sync.Mutex
+	failures int
+	ejected  bool
+	latency  time.Duration // moving average
+	blocks   int64
+	indexed  int64
+}
+
+// EndpointState is a snapshot of an endpoint's health.
+type EndpointState struct {
+	Url      string
+	Healthy  bool
+	Failures int
+	Latency  time.Duration
+	Blocks   int64
+	Indexed  int64
+}
+
+// NewEndpointPool creates a pool from a list of server URLs.
+func NewEndpointPool(urls ...string) (*EndpointPool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("empty endpoint list")
+	}
+	p := &EndpointPool{
+		MaxFailures:   DefaultMaxFailures,
+		ProbeInterval: DefaultProbeInterval,
+		MaxLag:        DefaultMaxLag,
+	}
+	for _, u := range urls {
+		q, err := ParseQuery(u)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %q: %w", u, err)
+		}
+		p.endpoints = append(p.endpoints, &Endpoint{base: q})
+	}
+	return p, nil
+}
+
+// WithMode sets the balancing mode.
+func (p *EndpointPool) WithMode(m BalanceMode) *EndpointPool {
+	p.Mode = m
+	return p
+}
+
+// Endpoints returns the current health state of all endpoints.
+func (p *EndpointPool) Endpoints() []EndpointState {
+	list := make([]EndpointState, len(p.endpoints))
+	for i, e := range p.endpoints {
+		e.mu.Lock()
+		list[i] = EndpointState{
+			Url:      e.base.Url(),
+			Healthy:  !e.ejected,
+			Failures: e.failures,
+			Latency:  e.latency,
+			Blocks:   e.blocks,
+			Indexed:  e.indexed,
+		}
+		e.mu.Unlock()
+	}
+	return list
+}
+
+// WithEndpoints distributes requests across the given servers with
+// round-robin balancing. The client URL serves as template for request
+// paths and is replaced by the selected endpoint on each request.
+func (c *Client) WithEndpoints(urls ...string) *Client {
+	if p, err := NewEndpointPool(urls...); err == nil {
+		c.WithEndpointPool(p)
+	}
+	return c
+}
+
+// WithEndpointPool installs a configured endpoint pool. Pass nil to send
+// all requests to the client URL again.
+func (c *Client) WithEndpointPool(p *EndpointPool) *Client {
+	return c.update(func(cfg *config) {
+		cfg.pool = p
+	})
+}
+
+func (c *Client) EndpointPool() *EndpointPool {
+	return c.config().pool
+}
+
+// wrap returns a handler that rewrites requests to a selected endpoint and
+// fails over to other endpoints when an idempotent request fails before
+// any data was delivered to the result.
+func (p *EndpointPool) wrap(cfg *config, next Handler) Handler {
+	return func(req *http.Request, result any) *Response {
+		prefix := cfg.base.Server
+		if cfg.base.Path != "" {
+			prefix += "/" + cfg.base.Path
+		}
+		if !strings.HasPrefix(req.URL.String(), prefix) {
+			return next(req, result)
+		}
+		p.maybeProbe(cfg)
+
+		var (
+			resp  *Response
+			tried = make(map[*Endpoint]bool)
+		)
+		for i := 0; i < len(p.endpoints); i++ {
+			e := p.pick(tried)
+			if e == nil {
+				break
+			}
+			tried[e] = true
+			r, err := e.rewrite(req, prefix, i > 0)
+			if err != nil {
+				return &Response{Err: err}
+			}
+			resp = next(r, result)
+			_, isOpen := IsErrCircuitOpen(resp.Err)
+			failed := isOpen || isNetError(resp.Err) || resp.StatusCode >= 500
+			e.record(resp.Latency, failed, p.maxFailures())
+			if !failed || req.Context().Err() != nil || !containsString(DefaultRetryMethods, req.Method) {
+				return resp
+			}
+			// streaming results may have consumed part of the body already,
+			// another attempt would deliver duplicate rows or bytes
+			if resp.StatusCode == http.StatusOK && !canShare(result) {
+				return resp
+			}
+			cfg.log.Debugf("endpoint %s failed, trying next: %v", e.base.Server, resp.Err)
+		}
+		return resp
+	}
+}
+
+func (p *EndpointPool) maxFailures() int {
+	if p.MaxFailures < 1 {
+		return 1
+	}
+	return p.MaxFailures
+}
+
+// pick selects the next endpoint that was not yet tried. It prefers
+// healthy endpoints whose index is closest to the chain head and falls
+// back to ejected endpoints when no healthy one is left.
+func (p *EndpointPool) pick(tried map[*Endpoint]bool) *Endpoint {
+	var (
+		head     int64
+		healthy  = make([]*Endpoint, 0, len(p.endpoints))
+		ejected  *Endpoint
+		minFails int
+	)
+	for _, e := range p.endpoints {
+		if tried[e] {
+			continue
+		}
+		e.mu.Lock()
+		ok, blocks, failures := !e.ejected, e.blocks, e.failures
+		e.mu.Unlock()
+		if !ok {
+			if ejected == nil || failures < minFails {
+				ejected, minFails = e, failures
+			}
+			continue
+		}
+		healthy = append(healthy, e)
+		if blocks > head {
+			head = blocks
+		}
+	}
+	if len(healthy) == 0 {
+		return ejected
+	}
+
+	// drop endpoints whose index lags behind the chain head (unknown lag
+	// counts as in sync), keep the least lagging ones when all lag
+	if head > 0 {
+		var (
+			synced  = make([]*Endpoint, 0, len(healthy))
+			closest []*Endpoint
+			minLag  int64
+		)
+		for _, e := range healthy {
+			e.mu.Lock()
+			indexed := e.indexed
+			e.mu.Unlock()
+			var lag int64
+			if indexed > 0 {
+				lag = head - indexed
+			}
+			if lag <= p.MaxLag {
+				synced = append(synced, e)
+			}
+			switch {
+			case closest == nil || lag < minLag:
+				closest, minLag = []*Endpoint{e}, lag
+			case lag == minLag:
+				closest = append(closest, e)
+			}
+		}
+		if len(synced) > 0 {
+			healthy = synced
+		} else {
+			healthy = closest
+		}
+	}
+
+	switch p.Mode {
+	case BalanceLatency:
+		var (
+			sel *Endpoint
+			min time.Duration
+		)
+		for _, e := range healthy {
+			e.mu.Lock()
+			l := e.latency
+			e.mu.Unlock()
+			if sel == nil || l < min {
+				sel, min = e, l
+			}
+		}
+		return sel
+	default:
+		n := atomic.AddUint32(&p.next, 1)
+		return healthy[int(n-1)%len(healthy)]
+	}
+}
+
+// maybeProbe refreshes endpoint status in the background once per probe
+// interval. Probes only run while a client is in use and are sent with the
+// settings of the client whose request triggered them.
+func (p *EndpointPool) maybeProbe(cfg *config) {
+	interval := p.ProbeInterval
+	if interval <= 0 {
+		return
+	}
+	now := time.Now().UnixNano()
+	if now-atomic.LoadInt64(&p.lastProbe) < int64(interval) {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&p.probing, 0, 1) {
+		return
+	}
+	atomic.StoreInt64(&p.lastProbe, now)
+	go func() {
+		defer atomic.StoreInt32(&p.probing, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+		var wg sync.WaitGroup
+		for _, e := range p.endpoints {
+			wg.Add(1)
+			go func(e *Endpoint) {
+				defer wg.Done()
+				p.probe(ctx, cfg, e)
+			}(e)
+		}
+		wg.Wait()
+	}()
+}
+
+// probe loads /explorer/status from an endpoint. A successful probe
+// readmits an ejected endpoint.
+func (p *EndpointPool) probe(ctx context.Context, cfg *config, e *Endpoint) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.base.WithPath("explorer/status").Url(), nil)
+	if err != nil {
+		return
+	}
+	for n, v := range cfg.headers {
+		req.Header[n] = append([]string(nil), v...)
+	}
+	req.Header.Set("User-Agent", cfg.userAgent)
+	req.Header.Set("Accept", "application/json")
+	start := time.Now()
+	resp, err := cfg.transport.Do(req)
+	if err != nil {
+		e.record(0, true, p.maxFailures())
+		return
+	}
+	defer resp.Body.Close()
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode >= 500 {
+		e.record(0, true, p.maxFailures())
+		return
+	}
+	var s endpointStatus
+	if resp.StatusCode == http.StatusOK {
+		_ = s.UnmarshalJSON(buf)
+	}
+	e.mu.Lock()
+	e.blocks = s.Blocks
+	e.indexed = s.Indexed
+	e.mu.Unlock()
+	e.record(time.Since(start), false, p.maxFailures())
+}
+
+// record updates health statistics after a request.
+func (e *Endpoint) record(latency time.Duration, failed bool, maxFailures int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if failed {
+		e.failures++
+		if e.failures >= maxFailures {
+			e.ejected = true
+		}
+		return
+	}
+	e.failures = 0
+	e.ejected = false
+	if latency > 0 {
+		if e.latency == 0 {
+			e.latency = latency
+		} else {
+			e.latency = (e.latency*7 + latency) / 8
+		}
+	}
+}
+
+// rewrite clones req and replaces the client URL prefix with the
+// endpoint URL. The body is rewound for repeated attempts.
+func (e *Endpoint) rewrite(req *http.Request, prefix string, again bool) (*http.Request, error) {
+	target := e.base.Server
+	if e.base.Path != "" {
+		target += "/" + e.base.Path
+	}
+	u, err := url.Parse(target + strings.TrimPrefix(req.URL.String(), prefix))
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.Host = ""
+	if again {
+		if err := rewindBody(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// endpointStatus mirrors the fields of the explorer status we use for
+// health checks.
+type endpointStatus struct {
+	Status    string  `json:"status"`
+	Blocks    int64   `json:"blocks"`
+	Finalized int64   `json:"finalized"`
+	Indexed   int64   `json:"indexed"`
+	Progress  float64 `json:"progress"`
+}
+
+func (s *endpointStatus) UnmarshalJSON(data []byte) error {
+	if len(data) < 2 || bytes.Equal(data, []byte(`null`)) {
+		return nil
+	}
+	if data[0] == '[' {
+		return Decode(data, nil, s)
+	}
+	type alias endpointStatus
+	return json.Unmarshal(data, (*alias)(s))
+}
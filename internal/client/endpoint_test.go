@@ -0,0 +1,267 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEndpointFailover(t *testing.T) {
+	// broken fails with a 503 or drops the connection after half a reply
+	newBroken := func(midBody bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/explorer/status" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if !midBody {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: 100\r\n\r\n[1,2,")
+			buf.Flush()
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+		}))
+	}
+
+	tests := []struct {
+		name     string
+		midBody  bool
+		result   func() any
+		wantNext bool
+	}{
+		{"status/json", false, func() any { return new([]int) }, true},
+		{"status/stream", false, func() any { return new(bytes.Buffer) }, true},
+		{"body/json", true, func() any { return new([]int) }, true},
+		{"body/stream", true, func() any { return new(bytes.Buffer) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// round-robin starts with the first endpoint
+			broken := newBroken(tt.midBody)
+			defer broken.Close()
+			var hits int32
+			good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/explorer/status" {
+					atomic.AddInt32(&hits, 1)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[1,2,3]`))
+			}))
+			defer good.Close()
+
+			pool, err := NewEndpointPool(broken.URL, good.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pool.ProbeInterval = 0
+			c := NewClient(broken.URL, nil).WithEndpointPool(pool)
+
+			err = c.Get(context.Background(), "/tables/op", nil, tt.result())
+			gotNext := atomic.LoadInt32(&hits) > 0
+			if gotNext != tt.wantNext {
+				t.Fatalf("failover=%t, want %t (err=%v)", gotNext, tt.wantNext, err)
+			}
+			if tt.wantNext && err != nil {
+				t.Errorf("unexpected error after failover: %v", err)
+			}
+			if !tt.wantNext && err == nil {
+				t.Errorf("expected error from broken stream")
+			}
+		})
+	}
+}
+
+// statusServer replies to API calls and status probes. It fails with
+// HTTP 503 while down is set.
+type statusServer struct {
+	*httptest.Server
+	down     atomic.Bool
+	hits     atomic.Int32
+	probes   atomic.Int32
+	probeKey atomic.Value
+}
+
+func newStatusServer(t *testing.T) *statusServer {
+	s := &statusServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/explorer/status" {
+			s.probeKey.Store(r.Header.Get("X-Api-Key"))
+			s.probes.Add(1)
+		} else {
+			s.hits.Add(1)
+		}
+		if s.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"synced","blocks":100,"indexed":100}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestEndpointEjection(t *testing.T) {
+	a, b := newStatusServer(t), newStatusServer(t)
+	pool, err := NewEndpointPool(a.URL, b.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.ProbeInterval = 0
+	pool.MaxFailures = 2
+	c := NewClient(a.URL, nil).WithRetryPolicy(nil).WithEndpointPool(pool)
+	ctx := context.Background()
+	healthy := func() string {
+		var s []string
+		for _, e := range pool.Endpoints() {
+			s = append(s, fmt.Sprint(e.Healthy))
+		}
+		return strings.Join(s, " ")
+	}
+
+	// requests fail over to b until a is ejected
+	a.down.Store(true)
+	for i := 0; i < 6; i++ {
+		if err := c.Get(ctx, "/explorer/tip", nil, nil); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if n := a.hits.Load(); n != 2 {
+		t.Errorf("ejected endpoint got %d requests, want 2", n)
+	}
+	if n := b.hits.Load(); n != 6 {
+		t.Errorf("healthy endpoint got %d requests, want 6", n)
+	}
+	if got := healthy(); got != "false true" {
+		t.Errorf("healthy %s", got)
+	}
+
+	// a failed probe keeps the endpoint ejected, a successful one readmits it
+	pool.probe(ctx, c.config(), pool.endpoints[0])
+	if got := healthy(); got != "false true" {
+		t.Errorf("healthy %s after failed probe", got)
+	}
+	a.down.Store(false)
+	pool.probe(ctx, c.config(), pool.endpoints[0])
+	if got := healthy(); got != "true true" {
+		t.Errorf("healthy %s after probe", got)
+	}
+	for i := 0; i < 4; i++ {
+		if err := c.Get(ctx, "/explorer/tip", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := a.hits.Load(); n != 4 {
+		t.Errorf("readmitted endpoint got %d requests, want 4", n)
+	}
+
+	// ejected endpoints are still used when no healthy one is left
+	a.down.Store(true)
+	b.down.Store(true)
+	for i := 0; i < 2; i++ {
+		c.Get(ctx, "/explorer/tip", nil, nil)
+	}
+	if got := healthy(); got != "false false" {
+		t.Errorf("healthy %s", got)
+	}
+	a.down.Store(false)
+	if err := c.Get(ctx, "/explorer/tip", nil, nil); err != nil {
+		t.Errorf("no fallback to ejected endpoints: %v", err)
+	}
+}
+
+func TestEndpointProbe(t *testing.T) {
+	srv := newStatusServer(t)
+	pool, err := NewEndpointPool(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.ProbeInterval = time.Hour
+	base := NewClient(srv.URL, nil).WithApiKey("base").WithEndpointPool(pool)
+	tenant := base.Clone().WithApiKey("tenant")
+
+	// probes are sent with the settings of the calling client
+	if err := tenant.Get(context.Background(), "/explorer/tip", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.probes.Load() == 0 || atomic.LoadInt32(&pool.probing) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no probe sent")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if key := srv.probeKey.Load(); key != "tenant" {
+		t.Errorf("probe sent with api key %q, want tenant", key)
+	}
+	if e := pool.Endpoints()[0]; e.Blocks != 100 || e.Indexed != 100 {
+		t.Errorf("status %+v", e)
+	}
+
+	// no more probes within the interval
+	if err := base.Get(context.Background(), "/explorer/tip", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.probes.Load(); n != 1 {
+		t.Errorf("%d probes sent, want 1", n)
+	}
+}
+
+func TestEndpointLag(t *testing.T) {
+	type state struct{ blocks, indexed int64 }
+	tests := []struct {
+		name   string
+		states []state
+		want   string
+	}{
+		{"unknown", []state{{0, 0}, {0, 0}, {0, 0}}, "0 1 2"},
+		{"synced", []state{{100, 100}, {100, 99}, {100, 98}}, "0 1 2"},
+		{"lagging", []state{{100, 100}, {100, 95}, {0, 0}}, "0 2"},
+		{"head", []state{{100, 98}, {100, 97}, {99, 97}}, "0"},
+		{"ahead", []state{{100, 100}, {101, 101}, {101, 99}}, "0 1 2"},
+		{"all_lagging", []state{{100, 90}, {100, 95}, {100, 95}}, "1 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, err := NewEndpointPool("http://a", "http://b", "http://c")
+			if err != nil {
+				t.Fatal(err)
+			}
+			idx := make(map[*Endpoint]int)
+			for i, e := range pool.endpoints {
+				e.blocks, e.indexed = tt.states[i].blocks, tt.states[i].indexed
+				idx[e] = i
+			}
+			seen := make(map[int]bool)
+			for i := 0; i < 2*len(pool.endpoints); i++ {
+				seen[idx[pool.pick(nil)]] = true
+			}
+			var got []int
+			for i := range seen {
+				got = append(got, i)
+			}
+			sort.Ints(got)
+			if s := strings.Trim(fmt.Sprint(got), "[]"); s != tt.want {
+				t.Errorf("picked %s, want %s", s, tt.want)
+			}
+		})
+	}
+}
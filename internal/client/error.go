@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -32,6 +31,43 @@ type HTTPError interface {
 	Body() []byte
 }
 
+// Sentinel errors classify failed requests by HTTP status. Use errors.Is
+// to test for them, the concrete error keeps the response details.
+//
+// The sentinel for HTTP 429 is ErrTooManyRequests because ErrRateLimited
+// names the concrete rate limit error type. Use errors.As with an
+// *ErrRateLimited to access the retry deadline.
+var (
+	ErrBadRequest        = errors.New("bad request")        // 400
+	ErrUnauthorized      = errors.New("unauthorized")       // 401
+	ErrForbidden         = errors.New("forbidden")          // 403
+	ErrNotFound          = errors.New("not found")          // 404
+	ErrTooManyRequests   = errors.New("too many requests")  // 429, see ErrRateLimited
+	ErrServerUnavailable = errors.New("server unavailable") // 5xx and open circuit
+)
+
+const headerRequestId = "X-Request-Id"
+
+// statusError returns the sentinel error for an HTTP status code.
+func statusError(code int) error {
+	switch {
+	case code == http.StatusBadRequest:
+		return ErrBadRequest
+	case code == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case code == http.StatusForbidden:
+		return ErrForbidden
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case code >= 500:
+		return ErrServerUnavailable
+	default:
+		return nil
+	}
+}
+
 var (
 	_ HTTPError = &ErrHttp{}
 	_ HTTPError = &ErrApi{}
@@ -47,6 +83,7 @@ type ErrApi struct {
 	RequestId string `json:"requestId"`
 	Reason    string `json:"reason"`
 	Request_  string `json:"request"`
+	http      *ErrHttp
 }
 
 func (e *ErrApi) Error() string {
@@ -82,19 +119,19 @@ func (e *ErrApi) UnmarshalJSON(buf []byte) error {
 		return err
 	}
 	// check if we have an embedded array and decode
-	type alias *ErrApi
+	type alias ErrApi
 	if v, ok := t["errors"]; ok {
 		var arr []alias
 		if err := json.Unmarshal(v, &arr); err != nil {
 			return err
 		}
 		if len(arr) > 0 {
-			*e = *arr[0]
+			*e = ErrApi(arr[0])
 		}
 		return nil
 	}
 	// if not, decode as single error
-	return json.Unmarshal(buf, alias(e))
+	return json.Unmarshal(buf, (*alias)(e))
 }
 
 func (e *ErrApi) Request() string {
@@ -102,9 +139,32 @@ func (e *ErrApi) Request() string {
 }
 
 func (e *ErrApi) StatusCode() int {
+	if e.Status_ == 0 && e.http != nil {
+		return e.http.statusCode
+	}
 	return e.Status_
 }
 
+// Header returns the headers of the response that carried the error.
+func (e *ErrApi) Header() http.Header {
+	if e.http == nil {
+		return nil
+	}
+	return e.http.header
+}
+
+// Unwrap returns the HTTP error the API error was decoded from.
+func (e *ErrApi) Unwrap() error {
+	if e.http == nil {
+		return nil
+	}
+	return e.http
+}
+
+func (e *ErrApi) Is(target error) bool {
+	return target != nil && target == statusError(e.StatusCode())
+}
+
 func (e *ErrApi) Status() string {
 	return e.Message
 }
@@ -115,7 +175,8 @@ func (e *ErrApi) Body() []byte {
 }
 
 func IsErrApi(err error) (*ErrApi, bool) {
-	e, ok := err.(*ErrApi)
+	var e *ErrApi
+	ok := errors.As(err, &e)
 	return e, ok
 }
 
@@ -147,13 +208,22 @@ func (e *ErrHttp) Body() []byte {
 	return e.body
 }
 
-func (e *ErrHttp) Decode(v interface{}) error {
-	return json.Unmarshal(e.body, v)
+// Header returns the response headers and trailers.
+func (e *ErrHttp) Header() http.Header {
+	return e.header
+}
+
+// RequestId returns the request id assigned by the API server.
+func (e *ErrHttp) RequestId() string {
+	return e.header.Get(headerRequestId)
+}
+
+func (e *ErrHttp) Is(target error) bool {
+	return target != nil && target == statusError(e.statusCode)
 }
 
-func newHttpError(resp *http.Response) *ErrHttp {
-	body, _ := io.ReadAll(resp.Body)
-	return newHttpErrorWithBody(resp, body)
+func (e *ErrHttp) Decode(v interface{}) error {
+	return json.Unmarshal(e.body, v)
 }
 
 func newHttpErrorWithBody(resp *http.Response, body []byte) *ErrHttp {
@@ -167,7 +237,8 @@ func newHttpErrorWithBody(resp *http.Response, body []byte) *ErrHttp {
 }
 
 func IsErrHttp(err error) (HTTPError, bool) {
-	e, ok := err.(HTTPError)
+	var e HTTPError
+	ok := errors.As(err, &e)
 	return e, ok
 }
 
@@ -177,8 +248,8 @@ type ErrRateLimited struct {
 	done     chan struct{}
 }
 
-func newRateLimitError(resp *http.Response, d time.Duration) *ErrRateLimited {
-	he := newHttpError(resp)
+func newRateLimitErrorWithBody(resp *http.Response, body []byte, d time.Duration) *ErrRateLimited {
+	he := newHttpErrorWithBody(resp, body)
 	e := &ErrRateLimited{
 		ErrHttp:  *he,
 		deadline: time.Now().UTC().Add(d),
@@ -214,24 +285,57 @@ func (e *ErrRateLimited) Deadline() time.Duration {
 	return e.deadline.Sub(time.Now().UTC())
 }
 
+// RateLimit returns the API quota reported with the rate limit response.
+func (e *ErrRateLimited) RateLimit() RateLimit {
+	r, _ := ParseRateLimit(e.header)
+	return r
+}
+
 func IsErrRateLimited(err error) (*ErrRateLimited, bool) {
-	e, ok := err.(*ErrRateLimited)
+	var e *ErrRateLimited
+	ok := errors.As(err, &e)
 	return e, ok
 }
 
 func ErrorStatus(err error) int {
-	switch e := err.(type) {
-	case *ErrRateLimited:
-		return 427
-	case *ErrHttp:
-		return e.statusCode
-	case *ErrApi:
-		return e.StatusCode()
+	var (
+		rl *ErrRateLimited
+		co *ErrCircuitOpen
+		ae *ErrApi
+		he *ErrHttp
+	)
+	switch {
+	case errors.As(err, &rl):
+		return http.StatusTooManyRequests
+	case errors.As(err, &co):
+		return http.StatusServiceUnavailable
+	case errors.As(err, &ae):
+		return ae.StatusCode()
+	case errors.As(err, &he):
+		return he.statusCode
 	default:
 		return 0
 	}
 }
 
+// DecodeError reports a table row or column that could not be decoded.
+type DecodeError struct {
+	Row    int    // row index in the reply
+	Column string // column name, empty when the row itself is malformed
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("decode: row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("decode: row %d column %q: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
 func isNetError(err error) bool {
 	if err == nil {
 		return false
@@ -0,0 +1,122 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		sentinel error
+		wantApi  bool
+	}{
+		{"bad_request", http.StatusBadRequest, `{"errors":[{"code":1001,"status":400,"message":"invalid limit"}]}`, ErrBadRequest, true},
+		{"unauthorized", http.StatusUnauthorized, `{"code":2001,"message":"missing api key"}`, ErrUnauthorized, true},
+		{"forbidden", http.StatusForbidden, `{"message":"denied"}`, ErrForbidden, true},
+		{"not_found", http.StatusNotFound, `{"errors":[{"code":3001,"message":"no account"}]}`, ErrNotFound, true},
+		{"server", http.StatusBadGateway, "<html>bad gateway</html>", ErrServerUnavailable, false},
+		{"rate_limit", http.StatusTooManyRequests, `{"message":"slow down"}`, ErrTooManyRequests, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set(headerRequestId, "req-42")
+				w.Header().Set("X-Test", tt.name)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL, nil).WithRetryPolicy(nil).WithRateLimitRetry(0, 0)
+			var v map[string]any
+			err := c.Get(context.Background(), "/explorer/account/tz1", nil, &v)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			// sentinels match by status, and only the matching one
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.sentinel)
+			}
+			for _, s := range []error{ErrBadRequest, ErrUnauthorized, ErrForbidden, ErrNotFound, ErrTooManyRequests, ErrServerUnavailable} {
+				if s != tt.sentinel && errors.Is(err, s) {
+					t.Errorf("error also matches %v", s)
+				}
+			}
+			if ErrorStatus(err) != tt.status {
+				t.Errorf("ErrorStatus = %d, want %d", ErrorStatus(err), tt.status)
+			}
+
+			// API errors unwrap to the HTTP error and keep its headers
+			var ae *ErrApi
+			if ok := errors.As(err, &ae); ok != tt.wantApi {
+				t.Fatalf("errors.As(*ErrApi) = %t, want %t for %T", ok, tt.wantApi, err)
+			}
+			if tt.wantApi {
+				if ae.RequestId != "req-42" || ae.Header().Get("X-Test") != tt.name || ae.StatusCode() != tt.status {
+					t.Errorf("api error lost response details: %+v", ae)
+				}
+				if !strings.Contains(ae.Request(), "/explorer/account/tz1") {
+					t.Errorf("api error request %q", ae.Request())
+				}
+				if errors.Unwrap(err) == nil {
+					t.Errorf("api error does not unwrap")
+				}
+			}
+			var he *ErrHttp
+			if tt.status != http.StatusTooManyRequests {
+				if !errors.As(err, &he) {
+					t.Fatalf("errors.As(*ErrHttp) failed for %T", err)
+				}
+				if he.RequestId() != "req-42" || he.StatusCode() != tt.status || string(he.Body()) != tt.body {
+					t.Errorf("http error %v: request id %q body %q", he, he.RequestId(), he.Body())
+				}
+			} else {
+				rl, ok := IsErrRateLimited(err)
+				if !ok || rl.RequestId() != "req-42" {
+					t.Errorf("rate limit error %T lost response details", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeErrorContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		fields  []string
+		wantRow int
+		wantCol string
+	}{
+		{"first_row", `[["x",1]]`, []string{"row_id", "height"}, 0, "row_id"},
+		{"later_row", `[[1,2],[3,4],[5,"y"]]`, []string{"row_id", "height"}, 2, "height"},
+		{"short_row", `[[1,2],[3]]`, []string{"row_id", "height"}, 1, "height"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []pageRow
+			err := DecodeSlice([]byte(tt.body), tt.fields, &rows)
+			var derr *DecodeError
+			if !errors.As(err, &derr) {
+				t.Fatalf("got %T %v, want *DecodeError", err, err)
+			}
+			if derr.Row != tt.wantRow || derr.Column != tt.wantCol {
+				t.Errorf("got row %d column %q, want row %d column %q", derr.Row, derr.Column, tt.wantRow, tt.wantCol)
+			}
+			if derr.Err == nil || errors.Unwrap(err) != derr.Err {
+				t.Errorf("decode error does not unwrap its cause")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter throttles outgoing requests with a token bucket and optionally
+// caps the number of concurrent in-flight requests. A single limiter is
+// shared by all APIs built on the same client.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second, 0 = unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+	slots  chan struct{} // nil = unlimited concurrency
+}
+
+// NewLimiter creates a limiter that allows rps requests per second with
+// bursts of up to burst requests and at most maxInFlight concurrent
+// requests. Zero values disable the respective limit.
+func NewLimiter(rps float64, burst, maxInFlight int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &Limiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// Rate returns the configured requests per second and burst size.
+func (l *Limiter) Rate() (float64, int) {
+	return l.rate, int(l.burst)
+}
+
+// MaxInFlight returns the concurrency limit or zero when unlimited.
+func (l *Limiter) MaxInFlight() int {
+	return cap(l.slots)
+}
+
+// InFlight returns the number of requests currently holding a slot.
+func (l *Limiter) InFlight() int {
+	return len(l.slots)
+}
+
+// Acquire blocks until a request may be sent or ctx is done. On success
+// the caller must call the returned release function once the request
+// has finished.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	if err := l.wait(ctx); err != nil {
+		return nil, err
+	}
+	if l.slots == nil {
+		return func() {}, nil
+	}
+	slots := l.slots
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	}
+}
+
+// wait reserves a token and sleeps until the reservation becomes valid.
+func (l *Limiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	var d time.Duration
+	if l.tokens < 0 {
+		d = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if d == 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		// return the unused token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
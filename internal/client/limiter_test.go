@@ -0,0 +1,73 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestLimiterPerAttempt checks that retries wait for the limiter like the
+// first attempt and that all slots are returned afterwards.
+func TestLimiterPerAttempt(t *testing.T) {
+	tests := []struct {
+		name   string
+		status []int
+		setup  func(c *Client) *Client
+	}{
+		{
+			name:   "backoff",
+			status: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			setup: func(c *Client) *Client {
+				p := NewBackoffPolicy(3)
+				p.MinDelay, p.Jitter = time.Millisecond, 0
+				return c.WithRetryPolicy(p)
+			},
+		},
+		{
+			name:   "rate_limit",
+			status: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK},
+			setup: func(c *Client) *Client {
+				return c.WithRateLimitRetry(3, 0)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.Header().Set("Retry-After", "0")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status[n-1])
+				w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			// a tiny refill rate makes every token count
+			l := NewLimiter(0.001, 10, 2)
+			c := tt.setup(NewClient(srv.URL, nil).WithLimiter(l))
+			var res map[string]any
+			if err := c.Get(context.Background(), "/explorer/status", nil, &res); err != nil {
+				t.Fatal(err)
+			}
+			if n := atomic.LoadInt32(&calls); n != 3 {
+				t.Fatalf("server saw %d requests, want 3", n)
+			}
+			l.mu.Lock()
+			tokens := l.tokens
+			l.mu.Unlock()
+			if tokens > 7.01 {
+				t.Errorf("limiter has %.2f tokens left, want 7", tokens)
+			}
+			if n := l.InFlight(); n != 0 {
+				t.Errorf("%d slots still in use", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// Handler sends a single API request and decodes the reply into result.
+type Handler func(req *http.Request, result any) *Response
+
+// Middleware wraps a handler to observe or modify requests and responses,
+// e.g. for metrics, audit logs, request signing or fault injection.
+type Middleware func(next Handler) Handler
+
+// Response is the outcome of a request as seen by middleware.
+type Response struct {
+	StatusCode int           // HTTP status, zero when no response was received
+	Header     http.Header   // response headers and trailers
+	Body       []byte        // raw body of failed requests
+	Latency    time.Duration // time spent sending and decoding incl. retries
+	Err        error         // transport, HTTP or decoded API error
+}
+
+// Use appends middleware to the client's handler chain. Middleware runs in
+// the order it was added, the first one sees the request first and the
+// response last.
+func (c *Client) Use(mw ...Middleware) *Client {
+	return c.update(func(cfg *config) {
+		cfg.middleware = append(cfg.middleware, mw...)
+	})
+}
+
+// chain builds the handler chain for cfg.
+func (c *Client) chain(cfg *config) Handler {
+	h := Handler(func(req *http.Request, result any) *Response {
+		return c.roundTrip(cfg, req, result)
+	})
+	if cfg.pool != nil {
+		h = cfg.pool.wrap(cfg, h)
+	}
+	if cfg.rcache != nil {
+		h = cfg.rcache.wrap(c, cfg, h)
+	}
+	for i := len(cfg.middleware) - 1; i >= 0; i-- {
+		h = cfg.middleware[i](h)
+	}
+	return h
+}
+
+// roundTrip is the innermost handler. It executes the request and decodes
+// API errors from the response body.
+func (c *Client) roundTrip(cfg *config, req *http.Request, result any) *Response {
+	start := time.Now()
+	responseChan := make(chan *response, 1)
+	c.handleRequest(cfg, &request{
+		httpRequest:  req,
+		responseVal:  result,
+		responseChan: responseChan,
+	})
+	r := <-responseChan
+	return &Response{
+		StatusCode: r.status,
+		Header:     r.headers,
+		Body:       r.result,
+		Latency:    time.Since(start),
+		Err:        decodeApiError(r.err),
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// traceMiddleware appends name to trace when the request passes in and
+// out of the middleware.
+func traceMiddleware(name string, trace *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request, result any) *Response {
+			*trace = append(*trace, name+">")
+			resp := next(req, result)
+			*trace = append(*trace, "<"+name)
+			return resp
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var trace []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace = append(trace, "server:"+r.Header.Get("X-Trace"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	tag := func(next Handler) Handler {
+		return func(req *http.Request, result any) *Response {
+			req.Header.Set("X-Trace", strings.Join(trace, ","))
+			return next(req, result)
+		}
+	}
+	c := NewClient(srv.URL, nil).
+		Use(traceMiddleware("a", &trace), traceMiddleware("b", &trace)).
+		Use(tag, traceMiddleware("c", &trace))
+	if err := c.Get(context.Background(), "/", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "a> b> c> server:a>,b> <c <b <a"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMiddlewareResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantStatus int
+		wantBody   string
+		wantErr    bool
+	}{
+		{"ok", http.StatusOK, `{"a":1}`, http.StatusOK, "", false},
+		{"not_found", http.StatusNotFound, `{"errors":[{"code":404,"status":404,"message":"no such block"}]}`, http.StatusNotFound, "no such block", true},
+		{"server_error", http.StatusInternalServerError, `oops`, http.StatusInternalServerError, "oops", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Runtime", "7")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			var seen *Response
+			c := NewClient(srv.URL, nil).WithRetryPolicy(nil).Use(func(next Handler) Handler {
+				return func(req *http.Request, result any) *Response {
+					seen = next(req, result)
+					return seen
+				}
+			})
+			var res map[string]int
+			err := c.Get(context.Background(), "/", nil, &res)
+			if seen == nil {
+				t.Fatal("middleware not called")
+			}
+			if seen.StatusCode != tt.wantStatus {
+				t.Errorf("status %d", seen.StatusCode)
+			}
+			if seen.Header.Get("X-Runtime") != "7" {
+				t.Errorf("header %v", seen.Header)
+			}
+			if seen.Latency <= 0 {
+				t.Errorf("latency %s", seen.Latency)
+			}
+			if !strings.Contains(string(seen.Body), tt.wantBody) || (tt.wantBody == "" && len(seen.Body) > 0) {
+				t.Errorf("body %q", seen.Body)
+			}
+			if (seen.Err != nil) != tt.wantErr || (err != nil) != tt.wantErr {
+				t.Fatalf("middleware saw %v, caller got %v", seen.Err, err)
+			}
+			var e *ErrApi
+			if tt.status == http.StatusNotFound && (!errors.As(seen.Err, &e) || e.Message != "no such block") {
+				t.Errorf("error %v not decoded from body", seen.Err)
+			}
+			if !tt.wantErr && res["a"] != 1 {
+				t.Errorf("result %v", res)
+			}
+		})
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"a":1}`))
+	}))
+	defer srv.Close()
+
+	errInjected := errors.New("injected")
+	var inner []string
+	c := NewClient(srv.URL, nil).WithRetryPolicy(nil).Use(
+		func(next Handler) Handler {
+			return func(req *http.Request, result any) *Response {
+				switch req.URL.Path {
+				case "/fail":
+					return &Response{StatusCode: http.StatusServiceUnavailable, Err: errInjected}
+				case "/empty":
+					return &Response{StatusCode: http.StatusOK}
+				}
+				return next(req, result)
+			}
+		},
+		traceMiddleware("inner", &inner),
+	)
+
+	var res map[string]int
+	if err := c.Get(context.Background(), "/fail", nil, &res); !errors.Is(err, errInjected) {
+		t.Errorf("got %v, want injected error", err)
+	}
+	if err := c.Get(context.Background(), "/empty", nil, &res); err != nil || res != nil {
+		t.Errorf("got %v %v, want untouched result", res, err)
+	}
+	if n := hits.Load(); n != 0 || len(inner) != 0 {
+		t.Errorf("short-circuited calls reached %d servers and %v", n, inner)
+	}
+	if err := c.Get(context.Background(), "/pass", nil, &res); err != nil || res["a"] != 1 {
+		t.Errorf("got %v %v", res, err)
+	}
+	if n := hits.Load(); n != 1 || len(inner) != 2 {
+		t.Errorf("got %d server hits and %v", n, inner)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// CallOption overrides client settings for a single API call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	apiKey   string
+	headers  http.Header
+	timeout  time.Duration
+	retry    RetryPolicy
+	hasRetry bool
+	noCache  bool
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a context that applies opts to all API calls made
+// with it. Options are added to those already present in ctx. Use this to
+// serve different tenants or latency budgets from a single client.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := &callOptions{}
+	if prev := callOptionsFrom(ctx); prev != nil {
+		*o = *prev
+		o.headers = prev.headers.Clone()
+	}
+	for _, fn := range opts {
+		fn(o)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+func callOptionsFrom(ctx context.Context) *callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(*callOptions)
+	return o
+}
+
+// CallApiKey sends the call with a different API key.
+func CallApiKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.apiKey = key
+	}
+}
+
+// CallHeader sets an extra HTTP header on the call.
+func CallHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		o.headers.Set(key, value)
+	}
+}
+
+// CallTimeout limits the total time of the call including retries.
+func CallTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = d
+	}
+}
+
+// CallRetryPolicy replaces the client's retry policy for the call. Pass nil
+// to disable retries.
+func CallRetryPolicy(p RetryPolicy) CallOption {
+	return func(o *callOptions) {
+		o.retry = p
+		o.hasRetry = true
+	}
+}
+
+// CallNoCache loads the reply from the API even when a cached copy exists.
+// The fresh reply still updates the response cache.
+func CallNoCache() CallOption {
+	return func(o *callOptions) {
+		o.noCache = true
+	}
+}
+
+// apply adds header overrides to a request.
+func (o *callOptions) apply(h http.Header) {
+	for n, v := range o.headers {
+		h[n] = append([]string(nil), v...)
+	}
+	if o.apiKey != "" {
+		h.Set("X-Api-Key", o.apiKey)
+	}
+}
+
+// retryPolicy returns the retry policy for a request.
+func (cfg *config) retryPolicy(ctx context.Context) RetryPolicy {
+	if o := callOptionsFrom(ctx); o != nil && o.hasRetry {
+		return o.retry
+	}
+	return cfg.retry
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// headerReply echoes selected request headers.
+type headerReply struct {
+	Key    string `json:"key"`
+	Tenant string `json:"tenant"`
+	Agent  string `json:"agent"`
+}
+
+func TestCallOptionsHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"key":"` + r.Header.Get("X-Api-Key") + `","tenant":"` + r.Header.Get("X-Tenant") + `","agent":"` + r.Header.Get("User-Agent") + `"}`))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil).WithApiKey("base").WithHeader("X-Tenant", "default").WithUserAgent("sdk")
+
+	bg := context.Background()
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want headerReply
+	}{
+		{"defaults", bg, headerReply{"base", "default", "sdk"}},
+		{"api_key", WithCallOptions(bg, CallApiKey("other")), headerReply{"other", "default", "sdk"}},
+		{"header", WithCallOptions(bg, CallHeader("X-Tenant", "b")), headerReply{"base", "b", "sdk"}},
+		{"user_agent", WithCallOptions(bg, CallHeader("User-Agent", "tool")), headerReply{"base", "default", "tool"}},
+		{"nested", WithCallOptions(WithCallOptions(bg, CallApiKey("outer"), CallHeader("X-Tenant", "a")), CallApiKey("inner")), headerReply{"inner", "a", "sdk"}},
+		{"empty_key", WithCallOptions(bg, CallApiKey("")), headerReply{"base", "default", "sdk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got headerReply
+			if err := c.Get(tt.ctx, "/", nil, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// options in a parent context are not changed by nested ones
+	outer := WithCallOptions(bg, CallHeader("X-Tenant", "a"))
+	_ = WithCallOptions(outer, CallHeader("X-Tenant", "b"))
+	var got headerReply
+	if err := c.Get(outer, "/", nil, &got); err != nil || got.Tenant != "a" {
+		t.Errorf("got %+v %v", got, err)
+	}
+}
+
+func TestCallOptionsTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil).WithTimeout(10 * time.Second).WithRetryPolicy(nil)
+
+	start := time.Now()
+	ctx := WithCallOptions(context.Background(), CallTimeout(20*time.Millisecond))
+	err := c.Get(ctx, "/", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("call took %s", d)
+	}
+}
+
+func TestCallOptionsRetry(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	backoff := func(num int) *BackoffPolicy {
+		p := NewBackoffPolicy(num)
+		p.MinDelay = time.Millisecond
+		return p
+	}
+	c := NewClient(srv.URL, nil).WithRetryPolicy(backoff(2))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantHits int32
+	}{
+		{"client", context.Background(), 3},
+		{"disabled", WithCallOptions(context.Background(), CallRetryPolicy(nil)), 1},
+		{"policy", WithCallOptions(context.Background(), CallRetryPolicy(backoff(1))), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			if err := c.Get(tt.ctx, "/", nil, nil); !errors.Is(err, ErrServerUnavailable) {
+				t.Errorf("got %v", err)
+			}
+			if n := hits.Load(); n != tt.wantHits {
+				t.Errorf("server got %d requests, want %d", n, tt.wantHits)
+			}
+		})
+	}
+}
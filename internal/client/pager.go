@@ -0,0 +1,163 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+)
+
+// TablePager walks a table query page by page. It follows the result
+// cursor until the server returns an empty page, the optional total row
+// cap is reached or the context is canceled.
+type TablePager[T any] struct {
+	query TableQuery[T]
+	page  *TableQueryResult[T]
+	total int
+	err   error
+	done  bool
+}
+
+// Pages returns a pager that starts at the query's current cursor. The query
+// limit is used as page size, MaxRows (when > 0) caps the total number of
+// rows returned across all pages.
+func (q TableQuery[T]) Pages() *TablePager[T] {
+	q.Filter = append(FilterList{}, q.Filter...)
+	return &TablePager[T]{query: q}
+}
+
+// Next fetches the next page. It returns false when there are no more rows
+// or when an error occurred. Call Err to distinguish between both cases.
+func (p *TablePager[T]) Next(ctx context.Context) bool {
+	if p.done {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		return p.fail(err)
+	}
+	if !p.query.capLimit(p.total) {
+		p.done = true
+		return false
+	}
+	res, err := p.query.Run(ctx)
+	if err != nil {
+		return p.fail(err)
+	}
+	if res.Len() == 0 {
+		p.page = res
+		p.done = true
+		return false
+	}
+	p.page = res
+	p.total += res.Len()
+
+	if !p.query.advance(res.Cursor()) {
+		p.done = true
+	}
+	return true
+}
+
+func (p *TablePager[T]) fail(err error) bool {
+	p.err = err
+	p.done = true
+	return false
+}
+
+// Page returns the most recently fetched page.
+func (p *TablePager[T]) Page() *TableQueryResult[T] {
+	return p.page
+}
+
+// Rows returns rows of the most recently fetched page.
+func (p *TablePager[T]) Rows() []T {
+	if p.page == nil {
+		return nil
+	}
+	return p.page.Rows()
+}
+
+// Err returns the error that stopped the pager, if any.
+func (p *TablePager[T]) Err() error {
+	return p.err
+}
+
+// Cursor returns the cursor from which the next page will be loaded. Use it
+// to resume an interrupted crawl.
+func (p *TablePager[T]) Cursor() uint64 {
+	return p.query.Cursor
+}
+
+// Total returns the number of rows fetched so far.
+func (p *TablePager[T]) Total() int {
+	return p.total
+}
+
+// Stream runs the query across all pages and sends rows one by one on the
+// returned channel. Rows are decoded while they arrive from the network, so
+// memory use stays flat regardless of page size. The channel is closed when
+// all rows were delivered, on error or when ctx is canceled. The error
+// channel receives at most one error and is closed afterwards.
+func (q TableQuery[T]) Stream(ctx context.Context) (<-chan T, <-chan error) {
+	rows := make(chan T)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(rows)
+		var total int
+		for q.capLimit(total) {
+			var (
+				n    int
+				last T
+			)
+			err := q.Each(ctx, func(v T) error {
+				select {
+				case rows <- v:
+					n++
+					last = v
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+			if err != nil {
+				errc <- err
+				return
+			}
+			if n == 0 {
+				return
+			}
+			total += n
+			if !q.advance(rowCursor(last)) {
+				return
+			}
+		}
+	}()
+	return rows, errc
+}
+
+// capLimit shrinks the page size so that no more than MaxRows rows are
+// loaded in total. It returns false when the cap is reached.
+func (q *TableQuery[T]) capLimit(total int) bool {
+	if q.MaxRows <= 0 {
+		return true
+	}
+	left := q.MaxRows - total
+	if left <= 0 {
+		return false
+	}
+	if q.Limit <= 0 || q.Limit > left {
+		q.Limit = left
+	}
+	return true
+}
+
+// advance moves the query cursor forward. It returns false when the row
+// type has no cursor column or the cursor did not move, otherwise paging
+// would load the same rows forever.
+func (q *TableQuery[T]) advance(cursor uint64) bool {
+	if cursor == 0 || cursor == q.Cursor {
+		return false
+	}
+	q.Cursor = cursor
+	return true
+}
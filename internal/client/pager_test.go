@@ -0,0 +1,231 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type pageRow struct {
+	RowId  uint64 `json:"row_id"`
+	Height int64  `json:"height"`
+}
+
+// tableServer serves rows 1..n of a table with row_id cursors and records
+// the limit of every request.
+type tableServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	limits []int
+}
+
+func newTableServer(t *testing.T, n int) *tableServer {
+	s := &tableServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		cursor, _ := strconv.Atoi(q.Get("cursor"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if q.Get("columns") != "row_id,height" {
+			t.Errorf("unexpected columns %q", q.Get("columns"))
+		}
+		s.mu.Lock()
+		s.limits = append(s.limits, limit)
+		s.mu.Unlock()
+		rows := make([]string, 0, limit)
+		for id := cursor + 1; id <= n && len(rows) < limit; id++ {
+			rows = append(rows, fmt.Sprintf("[%d,%d]", id, 100+id))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tableServer) Limits() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.limits...)
+}
+
+func TestTablePager(t *testing.T) {
+	tests := []struct {
+		name       string
+		rows       int
+		limit      int
+		maxRows    int
+		cursor     uint64
+		wantPages  []int
+		wantLimits []int
+		wantCursor uint64
+	}{
+		{
+			name:       "all_pages",
+			rows:       10,
+			limit:      4,
+			wantPages:  []int{4, 4, 2},
+			wantLimits: []int{4, 4, 4, 4},
+			wantCursor: 10,
+		},
+		{
+			name:       "exact_pages",
+			rows:       8,
+			limit:      4,
+			wantPages:  []int{4, 4},
+			wantLimits: []int{4, 4, 4},
+			wantCursor: 8,
+		},
+		{
+			name:       "resume",
+			rows:       10,
+			limit:      4,
+			cursor:     7,
+			wantPages:  []int{3},
+			wantLimits: []int{4, 4},
+			wantCursor: 10,
+		},
+		{
+			name:       "max_rows_partial_page",
+			rows:       10,
+			limit:      4,
+			maxRows:    6,
+			wantPages:  []int{4, 2},
+			wantLimits: []int{4, 2},
+			wantCursor: 6,
+		},
+		{
+			name:       "max_rows_below_limit",
+			rows:       10,
+			limit:      4,
+			maxRows:    3,
+			wantPages:  []int{3},
+			wantLimits: []int{3},
+			wantCursor: 3,
+		},
+		{
+			name:       "max_rows_above_total",
+			rows:       5,
+			limit:      4,
+			maxRows:    100,
+			wantPages:  []int{4, 1},
+			wantLimits: []int{4, 4, 4},
+			wantCursor: 5,
+		},
+		{
+			name:       "empty",
+			rows:       0,
+			limit:      4,
+			wantLimits: []int{4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTableServer(t, tt.rows)
+			q := NewTableQuery[pageRow](NewClient(srv.URL, nil), "op").
+				WithLimit(tt.limit).
+				WithMaxRows(tt.maxRows).
+				WithCursor(tt.cursor)
+			p := q.Pages()
+			var (
+				pages []int
+				next  = tt.cursor + 1
+			)
+			for p.Next(context.Background()) {
+				pages = append(pages, len(p.Rows()))
+				for _, r := range p.Rows() {
+					if r.RowId != next || r.Height != int64(100+next) {
+						t.Fatalf("got row %+v, want row_id %d", r, next)
+					}
+					next++
+				}
+			}
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(pages) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("page sizes %v, want %v", pages, tt.wantPages)
+			}
+			if got := srv.Limits(); fmt.Sprint(got) != fmt.Sprint(tt.wantLimits) {
+				t.Errorf("request limits %v, want %v", got, tt.wantLimits)
+			}
+			if p.Cursor() != tt.wantCursor && tt.wantCursor > 0 {
+				t.Errorf("cursor %d, want %d", p.Cursor(), tt.wantCursor)
+			}
+			if p.Total() != sum(pages) {
+				t.Errorf("total %d, want %d", p.Total(), sum(pages))
+			}
+			if p.Next(context.Background()) {
+				t.Errorf("Next returned true after the end")
+			}
+
+			// Stream must deliver the same rows
+			rows, errc := q.Stream(context.Background())
+			var n int
+			for range rows {
+				n++
+			}
+			if err := <-errc; err != nil {
+				t.Fatal(err)
+			}
+			if n != sum(pages) {
+				t.Errorf("stream delivered %d rows, want %d", n, sum(pages))
+			}
+		})
+	}
+}
+
+func TestTablePagerCancel(t *testing.T) {
+	srv := newTableServer(t, 100)
+	q := NewTableQuery[pageRow](NewClient(srv.URL, nil), "op").WithLimit(10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := q.Pages()
+	if !p.Next(ctx) {
+		t.Fatal(p.Err())
+	}
+	cancel()
+	if p.Next(ctx) {
+		t.Fatal("Next succeeded after cancel")
+	}
+	if !errors.Is(p.Err(), context.Canceled) {
+		t.Errorf("pager error %v, want context.Canceled", p.Err())
+	}
+	if p.Cursor() != 10 {
+		t.Errorf("cursor %d after cancel, want 10 to resume", p.Cursor())
+	}
+
+	// cancel a stream in the middle of the second page
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	rows, errc := q.Stream(ctx)
+	var n int
+	for r := range rows {
+		n++
+		if r.RowId == 15 {
+			cancel()
+		}
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("stream error %v, want context.Canceled", err)
+	}
+	if n < 15 || n >= 100 {
+		t.Errorf("stream delivered %d rows after cancel", n)
+	}
+}
+
+func sum(list []int) int {
+	var n int
+	for _, v := range list {
+		n += v
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var DefaultRateLimitWait = 5 * time.Second
+
+const (
+	headerRetryAfter = "Retry-After"
+	headerLimit      = "X-RateLimit-Limit"
+	headerRemaining  = "X-RateLimit-Remaining"
+	headerReset      = "X-RateLimit-Reset"
+)
+
+// RateLimit is the API quota reported by the server in response headers.
+type RateLimit struct {
+	Limit     int       // max requests per window
+	Remaining int       // requests left in the current window
+	Reset     time.Time // when the current window resets
+}
+
+// ParseRateLimit reads X-RateLimit-* headers (or their IETF draft
+// equivalents without X- prefix). The second return value is false when
+// the response carried no rate limit headers.
+func ParseRateLimit(h http.Header) (RateLimit, bool) {
+	var (
+		r  RateLimit
+		ok bool
+	)
+	if v := headerValue(h, headerLimit); v != "" {
+		// may carry a policy suffix like `100;w=60`
+		if n, err := strconv.Atoi(cutParams(v)); err == nil {
+			r.Limit = n
+			ok = true
+		}
+	}
+	if v := headerValue(h, headerRemaining); v != "" {
+		if n, err := strconv.Atoi(cutParams(v)); err == nil {
+			r.Remaining = n
+			ok = true
+		}
+	}
+	if v := headerValue(h, headerReset); v != "" {
+		if n, err := strconv.ParseInt(cutParams(v), 10, 64); err == nil {
+			// servers send either delta seconds or a unix timestamp
+			if n > 1e9 {
+				r.Reset = time.Unix(n, 0).UTC()
+			} else {
+				r.Reset = time.Now().UTC().Add(time.Duration(n) * time.Second)
+			}
+			ok = true
+		}
+	}
+	return r, ok
+}
+
+// Exhausted returns true when no requests are left in the current window
+// and the window has not yet reset.
+func (r RateLimit) Exhausted() bool {
+	return r.Limit > 0 && r.Remaining <= 0 && time.Now().Before(r.Reset)
+}
+
+// Wait blocks until the current window resets when the quota is exhausted.
+// It returns immediately otherwise.
+func (r RateLimit) Wait(ctx context.Context) error {
+	if !r.Exhausted() {
+		return nil
+	}
+	t := time.NewTimer(time.Until(r.Reset))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// parseRetryAfter returns the wait duration from a Retry-After header which
+// may contain delta seconds or an HTTP date. When missing it falls back to
+// the rate limit reset time and finally to the passed default.
+func parseRetryAfter(h http.Header, dflt time.Duration) time.Duration {
+	if v := h.Get(headerRetryAfter); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return time.Duration(n) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+	if r, ok := ParseRateLimit(h); ok && !r.Reset.IsZero() {
+		if d := time.Until(r.Reset); d > 0 {
+			return d
+		}
+	}
+	return dflt
+}
+
+func headerValue(h http.Header, key string) string {
+	if v := h.Get(key); v != "" {
+		return v
+	}
+	return h.Get(key[2:])
+}
+
+func cutParams(s string) string {
+	for i, c := range s {
+		if c == ';' || c == ',' {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// quotaTracker keeps the most recent rate limit state seen in responses.
+type quotaTracker struct {
+	sync.RWMutex
+	limit RateLimit
+}
+
+func (q *quotaTracker) update(h http.Header) {
+	if r, ok := ParseRateLimit(h); ok {
+		q.Lock()
+		q.limit = r
+		q.Unlock()
+	}
+}
+
+func (q *quotaTracker) get() RateLimit {
+	q.RLock()
+	defer q.RUnlock()
+	return q.limit
+}
@@ -5,6 +5,7 @@ package client
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -50,6 +51,19 @@ func NewStreamResponse(header http.Header) (StreamResponse, error) {
 	return r, nil
 }
 
+// NextCursor returns the streaming cursor as row id for use with
+// WithCursor. It returns zero when the server did not send a cursor.
+func (r StreamResponse) NextCursor() uint64 {
+	c, _ := strconv.ParseUint(r.Cursor, 10, 64)
+	return c
+}
+
+// StreamDecoder is implemented by result values that decode a response
+// directly from the HTTP body instead of from a fully buffered reply.
+type StreamDecoder interface {
+	DecodeStream(io.Reader) error
+}
+
 // request holds information about a request that is used to properly
 // detect, interpret, and deliver a reply to it.
 type request struct {
@@ -81,21 +95,26 @@ type FutureResult chan *response
 
 func (r FutureResult) Receive(ctx context.Context) error {
 	_, err := receiveFuture(ctx, r)
-	if err != nil {
-		if e, ok := IsErrRateLimited(err); ok {
-			return e
-		}
-		if e, ok := IsErrHttp(err); ok {
-			var ae ErrApi
-			if err := e.(*ErrHttp).Decode(&ae); err == nil {
-				ae.Request_ = e.(*ErrHttp).Request()
-				return &ae
-			}
-			return e
-		}
+	return decodeApiError(err)
+}
+
+// decodeApiError replaces a generic HTTP error with the API error
+// contained in its response body, if any.
+func decodeApiError(err error) error {
+	e, ok := err.(*ErrHttp)
+	if !ok {
 		return err
 	}
-	return nil
+	var ae ErrApi
+	if err := e.Decode(&ae); err == nil {
+		ae.Request_ = e.Request()
+		ae.http = e
+		if ae.RequestId == "" {
+			ae.RequestId = e.RequestId()
+		}
+		return &ae
+	}
+	return e
 }
 
 func (r FutureResult) Done() bool {
@@ -0,0 +1,190 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides whether a failed request is sent again and how long
+// to wait before. Retry is called after each failed attempt (starting at 1)
+// with either the transport error or the HTTP response. Returning false
+// stops retrying and hands the last result to the caller.
+type RetryPolicy interface {
+	Retry(req *http.Request, resp *http.Response, err error, attempt int, elapsed time.Duration) (time.Duration, bool)
+}
+
+// CheckRetryFunc classifies a failed attempt as retryable or not.
+type CheckRetryFunc func(req *http.Request, resp *http.Response, err error) bool
+
+var (
+	DefaultRetryMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	DefaultRetryStatus  = []int{http.StatusRequestTimeout, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	DefaultRetryCodes   = []int{http.StatusRequestTimeout, http.StatusGatewayTimeout}
+)
+
+// maxErrorPeek limits how much of an error body is read to classify it.
+const maxErrorPeek = 4096
+
+// BackoffPolicy retries idempotent requests on network errors and gateway
+// failures with exponential backoff and jitter.
+type BackoffPolicy struct {
+	MaxRetries  int           // max number of retries after the first attempt
+	MinDelay    time.Duration // delay before the first retry
+	MaxDelay    time.Duration // upper bound for a single delay
+	MaxElapsed  time.Duration // give up when the next attempt would start later (0 = no limit)
+	Multiplier  float64       // delay growth factor per attempt
+	Jitter      float64       // fraction of the delay that is randomized [0..1]
+	Methods     []string      // retryable HTTP methods
+	StatusCodes []int         // retryable HTTP status codes
+	ApiCodes    []int         // retryable API error codes, e.g. server-side timeouts
+	CheckRetry  CheckRetryFunc
+}
+
+// NewBackoffPolicy returns a policy with sensible defaults that retries
+// GET, HEAD and OPTIONS requests up to num times on network errors, 408,
+// 502, 503 and 504 responses and API errors that report a timeout.
+func NewBackoffPolicy(num int) *BackoffPolicy {
+	return &BackoffPolicy{
+		MaxRetries:  num,
+		MinDelay:    250 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		MaxElapsed:  5 * time.Minute,
+		Multiplier:  2,
+		Jitter:      0.5,
+		Methods:     DefaultRetryMethods,
+		StatusCodes: DefaultRetryStatus,
+		ApiCodes:    DefaultRetryCodes,
+	}
+}
+
+// WithCheck installs a hook that decides per failed attempt whether to
+// retry. The hook replaces the default method and status classification,
+// use IsRetryable from inside the hook to extend it.
+func (p *BackoffPolicy) WithCheck(fn CheckRetryFunc) *BackoffPolicy {
+	p.CheckRetry = fn
+	return p
+}
+
+func (p *BackoffPolicy) Retry(req *http.Request, resp *http.Response, err error, attempt int, elapsed time.Duration) (time.Duration, bool) {
+	if attempt > p.MaxRetries {
+		return 0, false
+	}
+	check := p.CheckRetry
+	if check == nil {
+		check = p.IsRetryable
+	}
+	if !check(req, resp, err) {
+		return 0, false
+	}
+	delay := p.Backoff(attempt)
+	if resp != nil && resp.Header.Get(headerRetryAfter) != "" {
+		if d := parseRetryAfter(resp.Header, 0); d > delay {
+			delay = d
+		}
+	}
+	if p.MaxElapsed > 0 && elapsed+delay > p.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+// IsRetryable is the default classification. It accepts idempotent
+// methods that failed with a network error, a retryable status code or an
+// API error with a retryable code.
+func (p *BackoffPolicy) IsRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if !containsString(p.Methods, req.Method) {
+		return false
+	}
+	if err != nil {
+		return isNetError(err)
+	}
+	if resp == nil {
+		return false
+	}
+	if containsInt(p.StatusCodes, resp.StatusCode) {
+		return true
+	}
+	if len(p.ApiCodes) == 0 {
+		return false
+	}
+	e := peekApiError(resp)
+	return e != nil && containsInt(p.ApiCodes, e.Code)
+}
+
+// peekApiError decodes the API error from the start of a response body
+// without consuming it.
+func peekApiError(resp *http.Response) *ErrApi {
+	if resp.Body == nil {
+		return nil
+	}
+	buf, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+	e := &ErrApi{}
+	if err := json.Unmarshal(buf, e); err != nil || e.Code == 0 {
+		return nil
+	}
+	return e
+}
+
+// Backoff returns the jittered delay before the given retry attempt.
+func (p *BackoffPolicy) Backoff(attempt int) time.Duration {
+	mul := p.Multiplier
+	if mul < 1 {
+		mul = 1
+	}
+	d := float64(p.MinDelay) * math.Pow(mul, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		j := math.Min(p.Jitter, 1)
+		d -= d * j * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// constantRetry retries network errors with a fixed delay. It implements
+// the behaviour of Client.WithRetry.
+type constantRetry struct {
+	num   int
+	delay time.Duration
+}
+
+func (p constantRetry) Retry(req *http.Request, _ *http.Response, err error, attempt int, _ time.Duration) (time.Duration, bool) {
+	if attempt > p.num || !isNetError(err) || req.Context().Err() != nil {
+		return 0, false
+	}
+	return p.delay, true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, i int) bool {
+	for _, v := range list {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoffRetryable(t *testing.T) {
+	apiError := func(code, status int) string {
+		return fmt.Sprintf(`{"errors":[{"code":%d,"status":%d,"message":"x"}]}`, code, status)
+	}
+	tests := []struct {
+		name   string
+		method string
+		status int
+		body   string
+		err    error
+		want   bool
+	}{
+		{"ok_status", http.MethodGet, http.StatusOK, "", nil, false},
+		{"request_timeout", http.MethodGet, http.StatusRequestTimeout, "", nil, true},
+		{"bad_gateway", http.MethodGet, http.StatusBadGateway, "", nil, true},
+		{"unavailable", http.MethodGet, http.StatusServiceUnavailable, "", nil, true},
+		{"gateway_timeout", http.MethodGet, http.StatusGatewayTimeout, "", nil, true},
+		{"server_error", http.MethodGet, http.StatusInternalServerError, apiError(1001, 500), nil, false},
+		{"bad_request", http.MethodGet, http.StatusBadRequest, apiError(1001, 400), nil, false},
+		{"api_timeout", http.MethodGet, http.StatusInternalServerError, apiError(http.StatusGatewayTimeout, 500), nil, true},
+		{"api_timeout_single", http.MethodGet, http.StatusInternalServerError, `{"code":408,"message":"x"}`, nil, true},
+		{"not_json", http.MethodGet, http.StatusInternalServerError, `timeout`, nil, false},
+		{"post_timeout", http.MethodPost, http.StatusRequestTimeout, "", nil, false},
+		{"post_api_timeout", http.MethodPost, http.StatusInternalServerError, apiError(408, 500), nil, false},
+		{"net_error", http.MethodGet, 0, "", &net.OpError{Op: "read", Err: errors.New("reset")}, true},
+		{"other_error", http.MethodGet, 0, "", errors.New("x"), false},
+	}
+	p := NewBackoffPolicy(3)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "http://x/", nil)
+			var resp *http.Response
+			if tt.status > 0 {
+				resp = &http.Response{
+					StatusCode: tt.status,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(tt.body)),
+				}
+			}
+			if got := p.IsRetryable(req, resp, tt.err); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+			// classification must not consume the body
+			if resp != nil {
+				if buf, _ := io.ReadAll(resp.Body); string(buf) != tt.body {
+					t.Errorf("body %q after classification", buf)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffApiTimeout(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"errors":[{"code":504,"status":500,"message":"query timeout"}]}`))
+		case 2:
+			w.WriteHeader(http.StatusRequestTimeout)
+		case 3:
+			w.Write([]byte(`{"a":1}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"errors":[{"code":1001,"status":500,"message":"broken"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := NewBackoffPolicy(3)
+	p.MinDelay, p.Jitter = time.Millisecond, 0
+	c := NewClient(srv.URL, nil).WithRetryPolicy(p)
+	var res map[string]int
+	if err := c.Get(context.Background(), "/", nil, &res); err != nil || res["a"] != 1 {
+		t.Fatalf("got %v %v", res, err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+
+	// other API errors are not retried and still reach the caller
+	err := c.Get(context.Background(), "/", nil, &res)
+	var e *ErrApi
+	if !errors.As(err, &e) || e.Message != "broken" {
+		t.Errorf("got %v, want API error", err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("server got %d requests, want 4", n)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"sync/atomic"
+
+	"blockwatch.cc/tzgo/tezos"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// ScriptCache stores decoded contract scripts by address. The default is
+// an in-memory 2Q cache, see index.NewFileScriptCache for a persistent one.
+type ScriptCache interface {
+	Get(key tezos.Address) (any, bool)
+	Add(key tezos.Address, val any)
+}
+
+var _ ScriptCache = (*lru.TwoQueueCache[tezos.Address, any])(nil)
+
+// CacheStats counts script cache lookups.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type cacheStats struct {
+	hits   uint64
+	misses uint64
+}
+
+func (s *cacheStats) count(hit bool) {
+	if hit {
+		atomic.AddUint64(&s.hits, 1)
+	} else {
+		atomic.AddUint64(&s.misses, 1)
+	}
+}
+
+func (s *cacheStats) get() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadUint64(&s.hits),
+		Misses: atomic.LoadUint64(&s.misses),
+	}
+}
@@ -7,9 +7,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-
-	// "io"
-	// "net/http"
+	"io"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
@@ -64,15 +63,18 @@ type TableQuery[T any] struct {
 	Format  FormatType // "json", "csv"
 	Columns []string
 	Limit   int
+	MaxRows int // total row cap for Pages and Stream
 	Cursor  uint64
 	Verbose bool
 	Prim    bool
 	NoFail  bool
+	Decode  bool // post-process rows with the decoder, see WithDecoder
 	Filter  FilterList
 	Order   OrderType // asc, desc
 	// OrderBy string // column name
 	// Sort string // asc/desc
-	client *Client
+	client  *Client
+	decoder func(context.Context, []T) error
 }
 
 func NewTableQuery[T any](c *Client, name string) *TableQuery[T] {
@@ -82,7 +84,7 @@ func NewTableQuery[T any](c *Client, name string) *TableQuery[T] {
 		panic(err)
 	}
 	return &TableQuery[T]{
-		Query:   c.base.Clone(),
+		Query:   c.config().base.Clone(),
 		Table:   name,
 		Format:  "json",
 		Limit:   DefaultLimit,
@@ -174,6 +176,11 @@ func (q *TableQuery[T]) WithLimit(limit int) *TableQuery[T] {
 	return q
 }
 
+func (q *TableQuery[T]) WithMaxRows(n int) *TableQuery[T] {
+	q.MaxRows = n
+	return q
+}
+
 func (q *TableQuery[T]) WithColumns(cols ...string) *TableQuery[T] {
 	q.Columns = cols
 	return q
@@ -219,6 +226,21 @@ func (q *TableQuery[T]) WithNoFail() *TableQuery[T] {
 	return q
 }
 
+// WithDecode enables client-side decoding of rows for tables that support
+// it, e.g. contract data of operations.
+func (q *TableQuery[T]) WithDecode() *TableQuery[T] {
+	q.Decode = true
+	return q
+}
+
+// WithDecoder installs a function that post-processes fetched rows when
+// Decode is set. Rows are passed in batches from Run and one by one from
+// Each and Stream.
+func (q *TableQuery[T]) WithDecoder(fn func(context.Context, []T) error) *TableQuery[T] {
+	q.decoder = fn
+	return q
+}
+
 func (q *TableQuery[T]) WithCursor(c uint64) *TableQuery[T] {
 	q.Cursor = c
 	return q
@@ -276,20 +298,99 @@ func (p TableQuery[T]) Url() string {
 	return base.WithPath("tables/" + p.Table + "." + string(format)).Url()
 }
 
+// Run runs the query and returns all rows of the result page. Rows are
+// decoded while the response is read from the network, the raw response
+// body is never held in memory.
 func (q TableQuery[T]) Run(ctx context.Context) (*TableQueryResult[T], error) {
 	if err := q.Check(); err != nil {
 		return nil, err
 	}
+	var headers http.Header
+	if q.Format == "csv" {
+		headers = make(http.Header)
+		headers.Set("Accept", "text/csv")
+	}
 	res := NewTableQueryResult[T](q.Columns)
-	if err := q.client.Get(ctx, q.Url(), nil, res); err != nil {
+	res.format = q.Format
+	if err := q.client.Get(ctx, q.Url(), headers, res); err != nil {
 		return nil, err
 	}
+	if q.Decode && q.decoder != nil {
+		if err := q.decoder(ctx, res.rows); err != nil {
+			return nil, err
+		}
+	}
 	return res, nil
 }
 
+// Each runs the query and calls fn for every row while the response is
+// decoded from the network. Memory use stays flat regardless of page size.
+// Both JSON and CSV formats are supported. Decoding stops at the first
+// error returned by fn.
+func (q TableQuery[T]) Each(ctx context.Context, fn func(T) error) error {
+	if err := q.Check(); err != nil {
+		return err
+	}
+	var headers http.Header
+	if q.Format == "csv" {
+		headers = make(http.Header)
+		headers.Set("Accept", "text/csv")
+	}
+	if q.Decode && q.decoder != nil {
+		next := fn
+		fn = func(v T) error {
+			if err := q.decoder(ctx, []T{v}); err != nil {
+				return err
+			}
+			return next(v)
+		}
+	}
+	return q.client.Get(ctx, q.Url(), headers, &tableRowDecoder[T]{
+		format:  q.Format,
+		columns: q.Columns,
+		fn:      fn,
+	})
+}
+
+// tableRowDecoder implements StreamDecoder for table responses.
+type tableRowDecoder[T any] struct {
+	format  FormatType
+	columns []string
+	fn      func(T) error
+}
+
+func (d *tableRowDecoder[T]) DecodeStream(r io.Reader) error {
+	if d.format == "csv" {
+		return DecodeCSV(r, d.fn)
+	}
+	return DecodeEach(r, d.columns, d.fn)
+}
+
+// StreamTo runs the query and copies the raw JSON or CSV response body to w
+// without decoding it. Cursor, row count, runtime and any error that occurs
+// after the response has started are read from the HTTP trailers. Use the
+// returned cursor to resume an interrupted download.
+func (q TableQuery[T]) StreamTo(ctx context.Context, w io.Writer) (StreamResponse, error) {
+	if err := q.Check(); err != nil {
+		return StreamResponse{}, err
+	}
+	// call with a non-nil header to indicate we expect response headers and trailers
+	headers := make(http.Header)
+	// signal upstream we accept trailers (required for some proxies to forward)
+	headers.Set("TE", "trailers")
+	if q.Format == "csv" {
+		headers.Set("Accept", "text/csv")
+	}
+	if err := q.client.Get(ctx, q.Url(), headers, w); err != nil {
+		return StreamResponse{}, err
+	}
+	return NewStreamResponse(headers)
+}
+
 type TableQueryResult[T any] struct {
 	rows    []T
 	columns []string
+	format  FormatType
 }
 
 func NewTableQueryResult[T any](cols []string) *TableQueryResult[T] {
@@ -310,6 +411,19 @@ func (r *TableQueryResult[T]) UnmarshalJSON(data []byte) error {
 	return DecodeSlice(data, r.columns, &r.rows)
 }
 
+// DecodeStream implements StreamDecoder and appends rows as they are
+// decoded from r.
+func (r *TableQueryResult[T]) DecodeStream(rd io.Reader) error {
+	fn := func(v T) error {
+		r.rows = append(r.rows, v)
+		return nil
+	}
+	if r.format == "csv" {
+		return DecodeCSV(rd, fn)
+	}
+	return DecodeEach(rd, r.columns, fn)
+}
+
 func (r *TableQueryResult[T]) Rows() []T {
 	return r.rows
 }
@@ -329,28 +443,23 @@ func (r *TableQueryResult[T]) Cursor() uint64 {
 	if len(r.rows) == 0 {
 		return 0
 	}
-	tinfo, _ := getTypeInfo(r.rows[0])
-	if !tinfo.Fields[0].ContainsFlag(fieldFlagUint64) {
+	return rowCursor(r.Last())
+}
+
+// rowCursor returns the row id stored in the first field of a table row
+// or zero if the row type has no uint64 id as first field.
+func rowCursor(row any) uint64 {
+	tinfo, err := getTypeInfo(row)
+	if err != nil || len(tinfo.Fields) == 0 || !tinfo.Fields[0].ContainsFlag(fieldFlagUint64) {
+		return 0
+	}
+	val := derefValue(reflect.ValueOf(row))
+	if !val.IsValid() {
 		return 0
 	}
-	val := derefValue(reflect.ValueOf(r.Last()))
 	return val.Field(tinfo.Fields[0].Idx[0]).Uint()
 }
 
-// func (c *Client) StreamTable(ctx context.Context, q TableQueryAPI, w io.Writer) (StreamResponse, error) {
-// 	if err := q.Check(); err != nil {
-// 		return StreamResponse{}, err
-// 	}
-// 	// call with a non-nil header to indicate we expect response headers and trailers
-// 	headers := make(http.Header)
-// 	// signal upstream we accept trailers (required for some proxies to forward)
-// 	headers.Add("TE", "trailers")
-// 	if err := c.Get(ctx, q.Url(), headers, w); err != nil {
-// 		return StreamResponse{}, err
-// 	}
-// 	return NewStreamResponse(headers)
-// }
-
 // func getTableColumn(data []byte, columns []string, name string) (string, bool) {
 // 	idx := colIndex(columns, name)
 // 	if idx < 0 || len(data) < 2 {
@@ -0,0 +1,216 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signalName reports on rowDecoded when it was unmarshaled.
+type signalName struct {
+	Name string
+}
+
+var rowDecoded = make(chan string, 16)
+
+func (n *signalName) UnmarshalJSON(buf []byte) error {
+	if err := json.Unmarshal(buf, &n.Name); err != nil {
+		return err
+	}
+	rowDecoded <- n.Name
+	return nil
+}
+
+type runRow struct {
+	RowId uint64     `json:"row_id"`
+	Name  signalName `json:"name"`
+}
+
+type nameRow struct {
+	RowId uint64 `json:"row_id"`
+	Name  string `json:"name"`
+}
+
+func TestTableQueryRunStreams(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[[1,"a"]`)
+		w.(http.Flusher).Flush()
+
+		// the client must decode the first row before the reply is complete
+		select {
+		case name := <-rowDecoded:
+			fmt.Fprintf(w, `,[2,"%s-then-b"]]`, name)
+		case <-time.After(5 * time.Second):
+			fmt.Fprint(w, `,[2,"buffered"]]`)
+		}
+	}))
+	defer srv.Close()
+
+	res, err := NewTableQuery[runRow](NewClient(srv.URL, nil), "test").WithColumns("row_id", "name").Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-rowDecoded
+	if res.Len() != 2 || res.Rows()[0].Name.Name != "a" || res.Rows()[1].Name.Name != "a-then-b" {
+		t.Errorf("rows %+v, want the second row sent after the first was decoded", res.Rows())
+	}
+	if res.Cursor() != 2 {
+		t.Errorf("cursor %d", res.Cursor())
+	}
+}
+
+func TestTableQueryRunErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantLen int
+		wantErr string
+	}{
+		{"rows", http.StatusOK, `[[1,"a"],[2,"b"]]`, 2, ""},
+		{"empty", http.StatusOK, `[]`, 0, ""},
+		{"null", http.StatusOK, `null`, 0, ""},
+		{"not_array", http.StatusOK, `{"row_id":1}`, 0, "expected JSON array"},
+		{"bad_row", http.StatusOK, `[[1,"a"],[2,3]]`, 0, `row 1 column "name"`},
+		{"api_error", http.StatusBadRequest, `{"errors":[{"code":400,"status":400,"message":"bad filter"}]}`, 0, "bad filter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			res, err := NewTableQuery[nameRow](NewClient(srv.URL, nil), "test").WithColumns("row_id", "name").Run(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Len() != tt.wantLen {
+				t.Errorf("got %d rows", res.Len())
+			}
+		})
+	}
+}
+
+func TestTableQueryStreamTo(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     FormatType
+		body       string
+		trailerErr string
+		wantAccept string
+		wantErr    string
+	}{
+		{
+			name:       "json",
+			format:     "json",
+			body:       `[[1,"a"],[2,"b"]]`,
+			wantAccept: "application/json",
+		},
+		{
+			name:       "csv",
+			format:     "csv",
+			body:       "row_id,name\n1,a\n2,b\n",
+			wantAccept: "text/csv",
+		},
+		{
+			name:       "trailer_error",
+			format:     "json",
+			body:       `[[1,"a"],[2,"b"]`,
+			trailerErr: `{"errors":[{"code":500,"status":500,"message":"query aborted"}]}`,
+			wantAccept: "application/json",
+			wantErr:    "query aborted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if te := r.Header.Get("TE"); te != "trailers" {
+					t.Errorf("TE header %q", te)
+				}
+				if accept := r.Header.Get("Accept"); accept != tt.wantAccept {
+					t.Errorf("Accept header %q", accept)
+				}
+				w.Header().Set("Trailer", "X-Streaming-Cursor, X-Streaming-Count, X-Streaming-Runtime, X-Streaming-Error")
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, tt.body)
+				w.Header().Set("X-Streaming-Cursor", "2")
+				w.Header().Set("X-Streaming-Count", "2")
+				w.Header().Set("X-Streaming-Runtime", "15")
+				if tt.trailerErr != "" {
+					w.Header().Set("X-Streaming-Error", tt.trailerErr)
+				}
+			}))
+			defer srv.Close()
+
+			var buf bytes.Buffer
+			res, err := NewTableQuery[nameRow](NewClient(srv.URL, nil), "test").
+				WithColumns("row_id", "name").
+				WithFormat(tt.format).
+				StreamTo(context.Background(), &buf)
+			if buf.String() != tt.body {
+				t.Errorf("got body %q, want %q", buf.String(), tt.body)
+			}
+			if tt.wantErr != "" {
+				var e *ErrApi
+				if !errors.As(err, &e) || e.Message != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if res.Cursor != "2" || res.NextCursor() != 2 || res.Count != 2 || res.Runtime != 15*time.Millisecond {
+				t.Errorf("got %+v", res)
+			}
+		})
+	}
+}
+
+func TestTableQueryStreamToAbort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nTransfer-Encoding: chunked\r\nTrailer: X-Streaming-Cursor\r\n\r\n")
+		buf.WriteString("8\r\n[[1,\"a\"]\r\n")
+		buf.Flush()
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	res, err := NewTableQuery[nameRow](NewClient(srv.URL, nil), "test").
+		WithColumns("row_id", "name").
+		StreamTo(context.Background(), &buf)
+	if err == nil {
+		t.Fatal("expected error for aborted stream")
+	}
+	if buf.String() != `[[1,"a"]` {
+		t.Errorf("got body %q, want the rows sent before the abort", buf.String())
+	}
+	if res.Cursor != "" || res.Count != 0 {
+		t.Errorf("got %+v from aborted stream", res)
+	}
+}
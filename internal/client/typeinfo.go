@@ -195,16 +195,47 @@ func structFieldInfo(f *reflect.StructField, tagname string) *FieldInfo {
 		// use this name
 		finfo.Alias = tags[0]
 	}
-	finfo.Flags = parseFlags(f.Tag.Get(flagName))
-	switch finfo.TypeName {
+	finfo.Flags = parseFlags(f.Tag.Get(flagName)) | typeFlags(finfo.TypeName)
+	return finfo
+}
+
+func typeFlags(typeName string) int {
+	switch typeName {
 	case "time.Time":
-		finfo.Flags |= fieldFlagTime
+		return fieldFlagTime
 	case "bool":
-		finfo.Flags |= fieldFlagBool
+		return fieldFlagBool
 	case "uint64":
-		finfo.Flags |= fieldFlagUint64
+		return fieldFlagUint64
 	}
-	return finfo
+	return 0
+}
+
+// findJsonField finds a field by its JSON name. Unlike TypeInfo.Find it
+// includes fields that are excluded from table columns.
+func findJsonField(typ reflect.Type, name string) (FieldInfo, bool) {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" || f.Anonymous {
+			continue
+		}
+		alias, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if alias != name {
+			continue
+		}
+		finfo := structFieldInfo(&f, "json")
+		if finfo == nil {
+			// excluded from tables
+			finfo = &FieldInfo{Idx: f.Index, Name: f.Name, TypeName: f.Type.String()}
+			finfo.Flags = typeFlags(finfo.TypeName)
+		}
+		finfo.Alias = alias
+		return *finfo, true
+	}
+	return FieldInfo{}, false
 }
 
 func addFieldInfo(typ reflect.Type, tinfo *TypeInfo, newf *FieldInfo) error {
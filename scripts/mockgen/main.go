@@ -0,0 +1,373 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// mockgen generates function-field fakes for all API interfaces of the SDK
+// packages below a source directory. Interfaces are found by name, every
+// exported interface type ending in "API" is included.
+//
+//	go run ./scripts/mockgen -src ./tzpro -out ./tzpro/mock/api_gen.go
+//	go run ./scripts/mockgen -src ./tzpro -out ./tzpro/mock/api_gen.go -check
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	src     string
+	out     string
+	pkgPath string
+	mockPkg string
+	check   bool
+)
+
+// reserved names are methods of the embedded Recorder
+var reserved = map[string]bool{
+	"Calls":           true,
+	"CallCount":       true,
+	"Called":          true,
+	"Reset":           true,
+	"AssertCalled":    true,
+	"AssertNotCalled": true,
+	"AssertCallCount": true,
+}
+
+func init() {
+	flag.StringVar(&src, "src", "tzpro", "SDK source directory")
+	flag.StringVar(&out, "out", "", "output file (default stdout)")
+	flag.StringVar(&pkgPath, "pkg", "blockwatch.cc/tzpro-go/tzpro", "import path of the source directory")
+	flag.StringVar(&mockPkg, "name", "mock", "package name of generated code")
+	flag.BoolVar(&check, "check", false, "fail when the output file is outdated")
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	apis, err := findInterfaces(src)
+	if err != nil {
+		return err
+	}
+	buf, err := generate(apis)
+	if err != nil {
+		return err
+	}
+	switch {
+	case out == "":
+		_, err = os.Stdout.Write(buf)
+		return err
+	case check:
+		old, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(old, buf) {
+			return fmt.Errorf("%s is outdated, run go generate", out)
+		}
+		return nil
+	default:
+		return os.WriteFile(out, buf, 0o644)
+	}
+}
+
+// api is an interface found in a source package.
+type api struct {
+	Pkg     string // package name
+	Name    string
+	Methods []method
+}
+
+type method struct {
+	Name    string
+	Params  []string // qualified type names
+	Results []string
+}
+
+func (m method) variadic() bool {
+	n := len(m.Params)
+	return n > 0 && strings.HasPrefix(m.Params[n-1], "...")
+}
+
+// imports maps package names used in generated code to import paths
+var imports = make(map[string]string)
+
+func findInterfaces(dir string) ([]api, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var apis []api
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == mockPkg {
+			continue
+		}
+		fset := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fset, filepath.Join(dir, e.Name()), func(fi os.FileInfo) bool {
+			return !strings.HasSuffix(fi.Name(), "_test.go")
+		}, 0)
+		if err != nil {
+			return nil, err
+		}
+		for name, pkg := range pkgs {
+			if name == "main" {
+				continue
+			}
+			for _, f := range pkg.Files {
+				found, err := fileInterfaces(name, pkgPath+"/"+e.Name(), f)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", fset.File(f.Pos()).Name(), err)
+				}
+				apis = append(apis, found...)
+			}
+		}
+	}
+	sort.Slice(apis, func(i, j int) bool {
+		if apis[i].Pkg != apis[j].Pkg {
+			return apis[i].Pkg < apis[j].Pkg
+		}
+		return apis[i].Name < apis[j].Name
+	})
+	return apis, nil
+}
+
+func fileInterfaces(pkg, path string, f *ast.File) ([]api, error) {
+	// local names of imported packages
+	fileImports := make(map[string]string)
+	for _, imp := range f.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := filepath.Base(p)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		fileImports[name] = p
+	}
+
+	var apis []api
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok || !ts.Name.IsExported() || !strings.HasSuffix(ts.Name.Name, "API") {
+				continue
+			}
+			imports[pkg] = path
+			q := &qualifier{pkg: pkg, path: path, imports: fileImports}
+			a := api{Pkg: pkg, Name: ts.Name.Name}
+			for _, m := range it.Methods.List {
+				ft, ok := m.Type.(*ast.FuncType)
+				if !ok {
+					return nil, fmt.Errorf("%s: embedded interfaces are not supported", ts.Name.Name)
+				}
+				for _, n := range m.Names {
+					if reserved[n.Name] {
+						return nil, fmt.Errorf("%s.%s: method name is reserved", ts.Name.Name, n.Name)
+					}
+					mm := method{Name: n.Name}
+					if mm.Params, ok = q.fields(ft.Params); !ok {
+						return nil, fmt.Errorf("%s.%s: unsupported parameter type", ts.Name.Name, n.Name)
+					}
+					if mm.Results, ok = q.fields(ft.Results); !ok {
+						return nil, fmt.Errorf("%s.%s: unsupported result type", ts.Name.Name, n.Name)
+					}
+					a.Methods = append(a.Methods, mm)
+				}
+			}
+			apis = append(apis, a)
+		}
+	}
+	return apis, nil
+}
+
+// qualifier prints type expressions as seen from the mock package.
+type qualifier struct {
+	pkg     string
+	path    string
+	imports map[string]string
+}
+
+func (q *qualifier) fields(l *ast.FieldList) ([]string, bool) {
+	if l == nil {
+		return nil, true
+	}
+	var types []string
+	for _, f := range l.List {
+		typ, ok := q.expr(f.Type)
+		if !ok {
+			return nil, false
+		}
+		n := len(f.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, typ)
+		}
+	}
+	return types, true
+}
+
+func (q *qualifier) expr(e ast.Expr) (string, bool) {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if !t.IsExported() {
+			return t.Name, true
+		}
+		imports[q.pkg] = q.path
+		return q.pkg + "." + t.Name, true
+	case *ast.SelectorExpr:
+		x, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", false
+		}
+		p, ok := q.imports[x.Name]
+		if !ok {
+			return "", false
+		}
+		imports[x.Name] = p
+		return x.Name + "." + t.Sel.Name, true
+	case *ast.StarExpr:
+		s, ok := q.expr(t.X)
+		return "*" + s, ok
+	case *ast.Ellipsis:
+		s, ok := q.expr(t.Elt)
+		return "..." + s, ok
+	case *ast.ArrayType:
+		s, ok := q.expr(t.Elt)
+		if t.Len == nil {
+			return "[]" + s, ok
+		}
+		l, isLit := t.Len.(*ast.BasicLit)
+		if !isLit {
+			return "", false
+		}
+		return "[" + l.Value + "]" + s, ok
+	case *ast.MapType:
+		k, ok1 := q.expr(t.Key)
+		v, ok2 := q.expr(t.Value)
+		return "map[" + k + "]" + v, ok1 && ok2
+	case *ast.InterfaceType:
+		if t.Methods == nil || len(t.Methods.List) == 0 {
+			return "any", true
+		}
+	}
+	return "", false
+}
+
+func generate(apis []api) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by mockgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", mockPkg)
+
+	// standard library imports first
+	names := make([]string, 0, len(imports))
+	for n := range imports {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		si, sj := isStdlib(imports[names[i]]), isStdlib(imports[names[j]])
+		if si != sj {
+			return si
+		}
+		return imports[names[i]] < imports[names[j]]
+	})
+	b.WriteString("import (\n")
+	for i, n := range names {
+		if i > 0 && isStdlib(imports[names[i-1]]) && !isStdlib(imports[n]) {
+			b.WriteString("\n")
+		}
+		if filepath.Base(imports[n]) == n {
+			fmt.Fprintf(&b, "\t%q\n", imports[n])
+		} else {
+			fmt.Fprintf(&b, "\t%s %q\n", n, imports[n])
+		}
+	}
+	b.WriteString(")\n\n")
+
+	// compile-time interface checks
+	b.WriteString("var (\n")
+	for _, a := range apis {
+		fmt.Fprintf(&b, "\t_ %s.%s = (*%s)(nil)\n", a.Pkg, a.Name, a.Name)
+	}
+	b.WriteString(")\n")
+
+	for _, a := range apis {
+		fmt.Fprintf(&b, "\n// %s is a configurable fake of %s.%s.\n", a.Name, a.Pkg, a.Name)
+		fmt.Fprintf(&b, "type %s struct {\n\tRecorder\n", a.Name)
+		for _, m := range a.Methods {
+			fmt.Fprintf(&b, "\t%sFunc func(%s)%s\n", m.Name, strings.Join(m.Params, ", "), results(m.Results))
+		}
+		b.WriteString("}\n")
+		for _, m := range a.Methods {
+			writeMethod(&b, a, m)
+		}
+	}
+	return format.Source(b.Bytes())
+}
+
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+func results(r []string) string {
+	switch len(r) {
+	case 0:
+		return ""
+	case 1:
+		return " " + r[0]
+	default:
+		return " (" + strings.Join(r, ", ") + ")"
+	}
+}
+
+func writeMethod(b *bytes.Buffer, a api, m method) {
+	params := make([]string, len(m.Params))
+	args := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = fmt.Sprintf("p%d %s", i, p)
+		args[i] = fmt.Sprintf("p%d", i)
+	}
+	call := strings.Join(args, ", ")
+	if m.variadic() {
+		call += "..."
+	}
+	fmt.Fprintf(b, "\nfunc (m *%s) %s(%s)%s {\n", a.Name, m.Name, strings.Join(params, ", "), results(m.Results))
+	fmt.Fprintf(b, "\tm.record(%s)\n", strings.Join(append([]string{strconv.Quote(m.Name)}, args...), ", "))
+	fmt.Fprintf(b, "\tif m.%sFunc != nil {\n", m.Name)
+	if len(m.Results) > 0 {
+		fmt.Fprintf(b, "\t\treturn m.%sFunc(%s)\n\t}\n", m.Name, call)
+	} else {
+		fmt.Fprintf(b, "\t\tm.%sFunc(%s)\n\t}\n}\n", m.Name, call)
+		return
+	}
+	zero := make([]string, len(m.Results))
+	for i, r := range m.Results {
+		zero[i] = fmt.Sprintf("r%d", i)
+		if i == len(m.Results)-1 && r == "error" {
+			zero[i] = fmt.Sprintf("notConfigured(%q)", a.Name+"."+m.Name)
+			continue
+		}
+		fmt.Fprintf(b, "\tvar r%d %s\n", i, r)
+	}
+	fmt.Fprintf(b, "\treturn %s\n}\n", strings.Join(zero, ", "))
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
+	"path/filepath"
 
 	// "runtime/debug"
 	"strings"
 
 	"blockwatch.cc/tzpro-go/tzpro"
+	"blockwatch.cc/tzpro-go/tzpro/cassette"
 	"github.com/echa/log"
 )
 
@@ -18,13 +21,23 @@ var (
 	verbose bool
 	vdebug  bool
 	vtrace  bool
+	fixture string
+	mode    string
+	live    bool
 )
 
+// defaultFixtures is where recorded API calls are kept. When it contains
+// fixtures the QA script replays them unless -live is set.
+const defaultFixtures = "scripts/qa/fixtures"
+
 func init() {
 	flag.StringVar(&api, "api", "https://api.tzpro.io", "use API")
 	flag.BoolVar(&verbose, "v", false, "verbose")
 	flag.BoolVar(&vdebug, "vv", false, "debug")
 	flag.BoolVar(&vtrace, "vvv", false, "trace")
+	flag.StringVar(&fixture, "fixtures", defaultFixtures, "record or replay API calls in this directory")
+	flag.StringVar(&mode, "mode", "replay", "fixture mode (replay, record, auto)")
+	flag.BoolVar(&live, "live", false, "use the live API, ignore fixtures")
 }
 
 func main() {
@@ -60,8 +73,22 @@ func run() error {
 	// use a placeholder calling context
 	ctx := context.Background()
 
+	// replay recorded API responses unless running live
+	m, err := cassette.ParseMode(mode)
+	if err != nil {
+		return err
+	}
+	if m == cassette.ModeReplay && fixture == defaultFixtures && !hasFixtures(fixture) {
+		fmt.Printf("No fixtures in %s, using live API.\n", fixture)
+		live = true
+	}
+	var hc *http.Client
+	if !live && fixture != "" {
+		hc = cassette.New(fixture, m).Client()
+	}
+
 	// create a new SDK client
-	c := tzpro.NewClient(api, nil).WithLogger(log.Log)
+	c := tzpro.NewClient(api, hc).WithLogger(log.Log)
 
 	tip := TestCommon(ctx, c)
 	if tip == nil {
@@ -71,7 +98,7 @@ func run() error {
 	TestWallet(ctx, c)
 	TestBaker(ctx, c)
 	TestContract(ctx, c)
-	TestMarket(ctx, c)
+	TestMarket(ctx, c, tip)
 	TestToken(ctx, c)
 	TestDex(ctx, c)
 	TestFarm(ctx, c)
@@ -87,3 +114,8 @@ func run() error {
 	}
 	return nil
 }
+
+func hasFixtures(dir string) bool {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	return len(files) > 0
+}
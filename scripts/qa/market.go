@@ -5,10 +5,11 @@ import (
 	"time"
 
 	"blockwatch.cc/tzpro-go/tzpro"
+	"blockwatch.cc/tzpro-go/tzpro/index"
 	"blockwatch.cc/tzpro-go/tzpro/market"
 )
 
-func TestMarket(ctx context.Context, c *tzpro.Client) {
+func TestMarket(ctx context.Context, c *tzpro.Client, tip *index.Tip) {
 	try("GetTickers", func() {
 		if _, err := c.Market.ListTickers(ctx); err != nil {
 			panic(err)
@@ -25,7 +26,7 @@ func TestMarket(ctx context.Context, c *tzpro.Client) {
 			Market:   "kraken",
 			Pair:     "XTZ_USD",
 			Collapse: tzpro.Collapse1d,
-			From:     time.Now().Add(-168 * time.Hour),
+			From:     tip.Timestamp.Truncate(time.Hour).Add(-168 * time.Hour), // stable for replay
 		}
 		if _, err := c.Market.ListCandles(ctx, args); err != nil {
 			panic(err)
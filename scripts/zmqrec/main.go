@@ -0,0 +1,94 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// zmqrec records TzPro ZMQ streams to a file and replays them.
+//
+//	go run ./scripts/zmqrec -zmq tcp://host:port -file stream.zmq
+//	go run ./scripts/zmqrec -file stream.zmq -speed 10 -from 5000000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"blockwatch.cc/tzpro-go/tzpro/zmq"
+	"github.com/echa/log"
+)
+
+var (
+	addr    string
+	file    string
+	topics  string
+	speed   float64
+	from    int64
+	verbose bool
+
+	out io.Writer = os.Stdout
+)
+
+func init() {
+	flag.StringVar(&addr, "zmq", "", "record from ZMQ address (e.g. tcp://host:port)")
+	flag.StringVar(&file, "file", "stream.zmq", "stream file")
+	flag.StringVar(&topics, "topics", "", "comma separated topics to record (default all)")
+	flag.Float64Var(&speed, "speed", 1, "replay speed, 0 replays without delay")
+	flag.Int64Var(&from, "from", 0, "replay from block height")
+	flag.BoolVar(&verbose, "v", false, "verbose")
+}
+
+func main() {
+	flag.Parse()
+	if verbose {
+		log.SetLevel(log.LevelDebug)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	run := replay
+	if addr != "" {
+		run = record
+	}
+	if err := run(ctx); err != nil && err != context.Canceled {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+func record(ctx context.Context) error {
+	rec, err := zmq.OpenRecorder(file)
+	if err != nil {
+		return err
+	}
+	defer rec.Close()
+	var t []string
+	if topics != "" {
+		t = strings.Split(topics, ",")
+	}
+	sub := zmq.NewSubscriber(addr, t...).WithLogger(log.Log)
+	return sub.Run(ctx, rec.Wrap(show))
+}
+
+func replay(ctx context.Context) error {
+	p, err := zmq.OpenPlayer(file)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	if from > 0 {
+		if err := p.SeekHeight(from); err == io.EOF {
+			return fmt.Errorf("%s: no messages at height %d", file, from)
+		} else if err != nil {
+			return err
+		}
+	}
+	return p.WithSpeed(speed).Run(ctx, show)
+}
+
+func show(m *zmq.Message) error {
+	fmt.Fprintf(out, "%s %-20s %d bytes\n", m.Time().Format("2006-01-02T15:04:05.000000"), m.Topic(), len(m.Body()))
+	return nil
+}
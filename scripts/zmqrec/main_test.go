@@ -0,0 +1,116 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"blockwatch.cc/tzpro-go/tzpro/zmq"
+)
+
+// writeStream records one status message and a block per height.
+func writeStream(t *testing.T, heights ...int64) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "stream.zmq")
+	rec, err := zmq.OpenRecorder(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+	rec.Record(zmq.NewMessage([]byte(zmq.TopicStatus), []byte(`["synced"]`)))
+	for _, h := range heights {
+		m := zmq.NewMessage([]byte(zmq.TopicRawBlock), []byte(fmt.Sprintf("[%d]", h))).WithFields([]string{"height"})
+		if err := rec.Record(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return name
+}
+
+// setFlags sets command line flags for one test and captures output.
+func setFlags(t *testing.T, name string, height int64) *bytes.Buffer {
+	oldFile, oldFrom, oldSpeed, oldOut := file, from, speed, out
+	t.Cleanup(func() { file, from, speed, out = oldFile, oldFrom, oldSpeed, oldOut })
+	var buf bytes.Buffer
+	file, from, speed, out = name, height, 0, &buf
+	return &buf
+}
+
+func TestReplay(t *testing.T) {
+	name := writeStream(t, 10, 11, 12)
+	tests := []struct {
+		from    int64
+		topics  []string
+		wantErr string
+	}{
+		{0, []string{"status", "raw_block", "raw_block", "raw_block"}, ""},
+		{11, []string{"raw_block", "raw_block"}, ""},
+		{13, nil, "no messages at height 13"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.from), func(t *testing.T) {
+			buf := setFlags(t, name, tt.from)
+			err := replay(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var topics []string
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				f := strings.Fields(line)
+				if len(f) != 4 || f[3] != "bytes" {
+					t.Fatalf("unexpected output line %q", line)
+				}
+				topics = append(topics, f[1])
+			}
+			if fmt.Sprint(topics) != fmt.Sprint(tt.topics) {
+				t.Errorf("replayed %v, want %v", topics, tt.topics)
+			}
+		})
+	}
+}
+
+func TestReplayErrors(t *testing.T) {
+	dir := t.TempDir()
+	setFlags(t, filepath.Join(dir, "missing.zmq"), 0)
+	if err := replay(context.Background()); !os.IsNotExist(err) {
+		t.Errorf("missing file: got %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.zmq")
+	os.WriteFile(bad, []byte("garbage"), 0o644)
+	setFlags(t, bad, 0)
+	if err := replay(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid stream") {
+		t.Errorf("bad file: got %v", err)
+	}
+}
+
+func TestRecordCanceled(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "new.zmq")
+	setFlags(t, name, 0)
+	oldAddr := addr
+	t.Cleanup(func() { addr = oldAddr })
+	addr = "tcp://127.0.0.1:1"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := record(ctx); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	// an empty stream file is ready for replay
+	if err := replay(context.Background()); err != nil {
+		t.Fatalf("replay of empty recording: %v", err)
+	}
+}
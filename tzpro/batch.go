@@ -0,0 +1,182 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tzpro
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchMode controls how a batch reacts to failed calls.
+type BatchMode byte
+
+const (
+	BatchCollectAll BatchMode = iota // run all calls and report errors per item
+	BatchFailFast                    // stop at the first error
+)
+
+var DefaultBatchConcurrency = 8
+
+// BatchCall is a single unit of work in a batch.
+type BatchCall[T any] func(ctx context.Context) (T, error)
+
+// BatchResult is the outcome of a batch call. Results are returned in the
+// order calls were added.
+type BatchResult[T any] struct {
+	Index int
+	Value T
+	Err   error
+}
+
+// Batch runs many API calls with bounded concurrency. Calls use the client
+// as usual, so client-side rate limits, retries and caches apply to each of
+// them.
+//
+//	b := tzpro.NewBatch[*index.Op](client)
+//	for _, h := range hashes {
+//		h := h
+//		b.Add(func(ctx context.Context) (*index.Op, error) {
+//			ops, err := client.Op.Get(ctx, h, tzpro.NoQuery)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return ops[0], nil
+//		})
+//	}
+//	res, err := b.Run(ctx)
+type Batch[T any] struct {
+	Concurrency int
+	Mode        BatchMode
+	calls       []BatchCall[T]
+}
+
+// NewBatch creates a batch for calls made with c. Concurrency defaults to
+// the client's concurrency limit, if any, or DefaultBatchConcurrency.
+func NewBatch[T any](c *Client) *Batch[T] {
+	n := DefaultBatchConcurrency
+	if c != nil {
+		if l := c.client.Limiter(); l != nil && l.MaxInFlight() > 0 {
+			n = l.MaxInFlight()
+		}
+	}
+	return &Batch[T]{Concurrency: n}
+}
+
+// WithConcurrency sets the max number of calls running at the same time.
+func (b *Batch[T]) WithConcurrency(n int) *Batch[T] {
+	b.Concurrency = n
+	return b
+}
+
+// WithMode sets the failure mode.
+func (b *Batch[T]) WithMode(m BatchMode) *Batch[T] {
+	b.Mode = m
+	return b
+}
+
+// Add appends calls to the batch.
+func (b *Batch[T]) Add(calls ...BatchCall[T]) *Batch[T] {
+	b.calls = append(b.calls, calls...)
+	return b
+}
+
+// Len returns the number of calls in the batch.
+func (b *Batch[T]) Len() int {
+	return len(b.calls)
+}
+
+// Run executes all calls and returns their results in order. In fail-fast
+// mode the first error cancels all remaining calls and is returned. When
+// ctx is done, calls that have not started fail with the context error,
+// which is returned as well.
+func (b *Batch[T]) Run(ctx context.Context) ([]BatchResult[T], error) {
+	results := make([]BatchResult[T], len(b.calls))
+	for i := range results {
+		results[i].Index = i
+	}
+	if len(b.calls) == 0 {
+		return results, nil
+	}
+	n := b.Concurrency
+	if n <= 0 {
+		n = DefaultBatchConcurrency
+	}
+	if n > len(b.calls) {
+		n = len(b.calls)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+		next  = make(chan int)
+	)
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				// the feeder may still hand out work after a cancel
+				if err := runCtx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				v, err := b.calls[i](runCtx)
+				results[i].Value, results[i].Err = v, err
+				if err != nil && b.Mode == BatchFailFast {
+					once.Do(func() {
+						first = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range b.calls {
+		select {
+		case next <- i:
+		case <-runCtx.Done():
+			for j := i; j < len(b.calls); j++ {
+				results[j].Err = runCtx.Err()
+			}
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if first != nil {
+		return results, first
+	}
+	return results, ctx.Err()
+}
+
+// BatchMap calls fn for each key with bounded concurrency on client c and
+// returns results in key order. It is a shortcut for building a Batch.
+func BatchMap[K, T any](ctx context.Context, c *Client, keys []K, fn func(context.Context, K) (T, error)) ([]BatchResult[T], error) {
+	b := NewBatch[T](c)
+	for _, k := range keys {
+		k := k
+		b.Add(func(ctx context.Context) (T, error) {
+			return fn(ctx, k)
+		})
+	}
+	return b.Run(ctx)
+}
+
+// Values returns the values of all successful calls in order.
+func Values[T any](results []BatchResult[T]) []T {
+	vals := make([]T, 0, len(results))
+	for _, r := range results {
+		if r.Err == nil {
+			vals = append(vals, r.Value)
+		}
+	}
+	return vals
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tzpro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchOrder(t *testing.T) {
+	b := NewBatch[int](nil).WithConcurrency(4)
+	for i := 0; i < 20; i++ {
+		i := i
+		b.Add(func(context.Context) (int, error) {
+			// later calls finish first
+			time.Sleep(time.Duration(20-i) * 100 * time.Microsecond)
+			return i * i, nil
+		})
+	}
+	res, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 20 {
+		t.Fatalf("got %d results", len(res))
+	}
+	for i, r := range res {
+		if r.Index != i || r.Value != i*i || r.Err != nil {
+			t.Errorf("result %d: %+v", i, r)
+		}
+	}
+
+	res, err = NewBatch[int](nil).Run(context.Background())
+	if err != nil || len(res) != 0 {
+		t.Errorf("empty batch: %v %v", res, err)
+	}
+}
+
+func TestBatchConcurrency(t *testing.T) {
+	for _, n := range []int{1, 3, 8, 50} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			var active, max atomic.Int32
+			b := NewBatch[int](nil).WithConcurrency(n)
+			for i := 0; i < 20; i++ {
+				b.Add(func(context.Context) (int, error) {
+					a := active.Add(1)
+					for {
+						m := max.Load()
+						if a <= m || max.CompareAndSwap(m, a) {
+							break
+						}
+					}
+					time.Sleep(time.Millisecond)
+					active.Add(-1)
+					return 0, nil
+				})
+			}
+			if _, err := b.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			want := n
+			if want > 20 {
+				want = 20
+			}
+			if m := int(max.Load()); m > want || (n > 1 && m < 2) {
+				t.Errorf("%d calls ran at the same time, limit %d", m, want)
+			}
+		})
+	}
+
+	// defaults follow the client's concurrency limit
+	if n := NewBatch[int](nil).Concurrency; n != DefaultBatchConcurrency {
+		t.Errorf("default concurrency %d", n)
+	}
+	c := NewClient("http://localhost", nil).WithMaxConcurrency(3)
+	if n := NewBatch[int](c).Concurrency; n != 3 {
+		t.Errorf("concurrency %d, want client limit 3", n)
+	}
+}
+
+func TestBatchFailFast(t *testing.T) {
+	errFail := errors.New("fail")
+	var started, canceled atomic.Int32
+	running := make(chan struct{}, 3)
+	b := NewBatch[int](nil).WithConcurrency(3).WithMode(BatchFailFast)
+	for i := 0; i < 10; i++ {
+		i := i
+		b.Add(func(ctx context.Context) (int, error) {
+			started.Add(1)
+			if i == 2 {
+				// fail once the other workers are busy
+				<-running
+				<-running
+				return 0, errFail
+			}
+			running <- struct{}{}
+			<-ctx.Done()
+			canceled.Add(1)
+			return 0, ctx.Err()
+		})
+	}
+	res, err := b.Run(context.Background())
+	if err != errFail {
+		t.Fatalf("got %v, want first error", err)
+	}
+	if n := started.Load(); n != 3 {
+		t.Errorf("%d calls started after failure, want 3", n)
+	}
+	if n := canceled.Load(); n != 2 {
+		t.Errorf("%d in-flight calls canceled, want 2", n)
+	}
+	for i, r := range res {
+		switch {
+		case i == 2:
+			if r.Err != errFail {
+				t.Errorf("result %d: %v", i, r.Err)
+			}
+		case !errors.Is(r.Err, context.Canceled):
+			t.Errorf("result %d: got %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
+
+func TestBatchCollectAll(t *testing.T) {
+	res, err := BatchMap(context.Background(), nil, []int{0, 1, 2, 3, 4, 5}, func(_ context.Context, k int) (string, error) {
+		if k%2 == 1 {
+			return "", fmt.Errorf("odd %d", k)
+		}
+		return fmt.Sprint("v", k), nil
+	})
+	if err != nil {
+		t.Fatalf("collect all returned %v", err)
+	}
+	for i, r := range res {
+		if i%2 == 1 {
+			if r.Err == nil || r.Err.Error() != fmt.Sprint("odd ", i) {
+				t.Errorf("result %d: got error %v", i, r.Err)
+			}
+		} else if r.Err != nil || r.Value != fmt.Sprint("v", i) {
+			t.Errorf("result %d: %+v", i, r)
+		}
+	}
+	if got := fmt.Sprint(Values(res)); got != "[v0 v2 v4]" {
+		t.Errorf("values %s", got)
+	}
+}
+
+func TestBatchParentCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	var done []int
+	b := NewBatch[int](nil).WithConcurrency(2)
+	for i := 0; i < 10; i++ {
+		i := i
+		b.Add(func(ctx context.Context) (int, error) {
+			if i == 3 {
+				cancel()
+			}
+			if i >= 2 {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}
+			mu.Lock()
+			done = append(done, i)
+			mu.Unlock()
+			return i, nil
+		})
+	}
+	res, err := b.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if len(done) != 2 {
+		t.Errorf("completed calls %v, want 0 and 1", done)
+	}
+	for i, r := range res {
+		if i < 2 && (r.Err != nil || r.Value != i) {
+			t.Errorf("result %d: %+v", i, r)
+		}
+		if i >= 2 && !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("result %d: got %v, want context.Canceled", i, r.Err)
+		}
+	}
+}
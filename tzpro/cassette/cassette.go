@@ -0,0 +1,328 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Package cassette records HTTP interactions with the TzPro API to fixture
+// files and replays them later. Use it to run code built on the SDK in tests
+// without network access.
+//
+//	rec := cassette.New("testdata/fixtures", cassette.ModeReplay)
+//	client := tzpro.NewClient("https://api.tzpro.io", rec.Client())
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"blockwatch.cc/tzpro-go/internal/util"
+)
+
+// Mode selects whether interactions are recorded or replayed.
+type Mode byte
+
+const (
+	ModeReplay Mode = iota // serve from fixtures, fail on unknown requests
+	ModeRecord             // always send to the API and overwrite fixtures
+	ModeAuto               // replay known requests, record unknown ones
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModeAuto:
+		return "auto"
+	default:
+		return "invalid"
+	}
+}
+
+// ParseMode parses a mode name as used in command line flags.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "replay":
+		return ModeReplay, nil
+	case "record":
+		return ModeRecord, nil
+	case "auto":
+		return ModeAuto, nil
+	default:
+		return 0, fmt.Errorf("cassette: invalid mode %q", s)
+	}
+}
+
+// ErrNotRecorded is returned in replay mode for requests without fixture.
+var ErrNotRecorded = errors.New("cassette: request not recorded")
+
+// DefaultRedact lists request headers whose values are never written to
+// fixture files.
+var DefaultRedact = []string{"X-Api-Key", "Authorization", "Cookie"}
+
+// DefaultRedactResponse lists response headers and trailers whose values
+// are never written to fixture files.
+var DefaultRedactResponse = []string{"Set-Cookie", "Authorization", "Proxy-Authenticate", "WWW-Authenticate", "X-Api-Key"}
+
+const redacted = "REDACTED"
+
+// Recorder is an http.RoundTripper that records and replays interactions.
+// Requests are matched by method, URL path, sorted query parameters and
+// body. Scheme and host are ignored, so fixtures recorded against one
+// server replay for any other.
+type Recorder struct {
+	Mode           Mode
+	Dir            string
+	Transport      http.RoundTripper // upstream transport used for recording
+	Redact         []string          // request headers to redact
+	RedactResponse []string          // response headers and trailers to redact
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// New creates a recorder that stores fixtures in dir.
+func New(dir string, mode Mode) *Recorder {
+	return &Recorder{
+		Mode:           mode,
+		Dir:            dir,
+		Transport:      http.DefaultTransport,
+		Redact:         DefaultRedact,
+		RedactResponse: DefaultRedactResponse,
+	}
+}
+
+// Client returns an HTTP client that uses the recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{
+		Transport: r,
+		Timeout:   60 * time.Second,
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := Key(req, body)
+	name := filepath.Join(r.Dir, fileName(req, key))
+
+	if r.Mode != ModeRecord {
+		it, err := readInteraction(name)
+		switch {
+		case err == nil:
+			return it.Response.response(req), nil
+		case !os.IsNotExist(err):
+			return nil, err
+		case r.Mode == ModeReplay:
+			return nil, fmt.Errorf("%w: %s %s", ErrNotRecorded, req.Method, key)
+		}
+	}
+	return r.record(req, body, name)
+}
+
+// record sends req upstream and stores the full interaction.
+func (r *Recorder) record(req *http.Request, body []byte, name string) (*http.Response, error) {
+	tr := r.Transport
+	if tr == nil {
+		tr = http.DefaultTransport
+	}
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read the full body so trailers become available
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	it := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			Url:    req.URL.RequestURI(),
+			Header: redact(req.Header, r.Redact),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     redact(resp.Header, r.RedactResponse),
+			Trailer:    redact(resp.Trailer, r.RedactResponse),
+		},
+		Recorded: time.Now().UTC(),
+	}
+	it.Request.setBody(body)
+	it.Response.setBody(buf)
+	if err := writeInteraction(name, it); err != nil {
+		return nil, err
+	}
+	return it.Response.response(req), nil
+}
+
+// redact returns a copy of h with the values of all listed headers
+// replaced. Headers with several values keep their number of values.
+func redact(h http.Header, names []string) http.Header {
+	h = h.Clone()
+	for _, n := range names {
+		vals := h[http.CanonicalHeaderKey(n)]
+		for i := range vals {
+			vals[i] = redacted
+		}
+	}
+	return h
+}
+
+// Key returns the normalized form of a request used for matching.
+func Key(req *http.Request, body []byte) string {
+	q := req.URL.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(path.Clean("/" + req.URL.Path))
+	for i, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for j, v := range vals {
+			if i == 0 && j == 0 {
+				b.WriteByte('?')
+			} else {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+	if len(body) > 0 {
+		h := sha256.Sum256(body)
+		b.WriteString("#")
+		b.WriteString(hex.EncodeToString(h[:8]))
+	}
+	return b.String()
+}
+
+// fileName builds a readable, unique fixture file name.
+func fileName(req *http.Request, key string) string {
+	h := sha256.Sum256([]byte(req.Method + " " + key))
+	p := strings.Trim(req.URL.Path, "/")
+	p = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, p)
+	if len(p) > 80 {
+		p = p[:80]
+	}
+	return fmt.Sprintf("%s_%s_%s.json", req.Method, p, hex.EncodeToString(h[:6]))
+}
+
+// Interaction is a recorded request/response pair as stored in a fixture.
+type Interaction struct {
+	Request  Request   `json:"request"`
+	Response Response  `json:"response"`
+	Recorded time.Time `json:"recorded"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	Base64 bool        `json:"base64,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Trailer    http.Header `json:"trailer,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Base64     bool        `json:"base64,omitempty"`
+}
+
+func (r *Request) setBody(buf []byte) {
+	r.Body, r.Base64 = encodeBody(buf)
+}
+
+func (r *Response) setBody(buf []byte) {
+	r.Body, r.Base64 = encodeBody(buf)
+}
+
+func encodeBody(buf []byte) (string, bool) {
+	if utf8.Valid(buf) {
+		return string(buf), false
+	}
+	return base64.StdEncoding.EncodeToString(buf), true
+}
+
+func (r *Response) body() []byte {
+	if r.Base64 {
+		buf, _ := base64.StdEncoding.DecodeString(r.Body)
+		return buf
+	}
+	return []byte(r.Body)
+}
+
+// response builds an HTTP response for req from the recorded reply.
+func (r *Response) response(req *http.Request) *http.Response {
+	buf := r.body()
+	return &http.Response{
+		Status:        r.Status,
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Trailer:       r.Trailer.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(buf)),
+		ContentLength: int64(len(buf)),
+		Request:       req,
+	}
+}
+
+func readInteraction(name string) (*Interaction, error) {
+	buf, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	it := &Interaction{}
+	if err := json.Unmarshal(buf, it); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", name, err)
+	}
+	return it, nil
+}
+
+func writeInteraction(name string, it *Interaction) error {
+	buf, err := json.MarshalIndent(it, "", "  ")
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(name, buf)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package cassette
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		body string
+		want string
+	}{
+		{"plain", "https://api.tzpro.io/explorer/tip", "", "/explorer/tip"},
+		{"host_ignored", "http://localhost:8000/explorer/tip", "", "/explorer/tip"},
+		{"clean_path", "https://api.tzpro.io//explorer/./block/../tip", "", "/explorer/tip"},
+		{"sorted_keys", "https://api.tzpro.io/tables/op?limit=2&columns=hash", "", "/tables/op?columns=hash&limit=2"},
+		{"sorted_values", "https://api.tzpro.io/tables/op?type=b&type=a", "", "/tables/op?type=a&type=b"},
+		{"escaped", "https://api.tzpro.io/tables/op?sender.in=tz1a%2Ctz1b", "", "/tables/op?sender.in=tz1a%2Ctz1b"},
+		{"body", "https://api.tzpro.io/v1/query", `{"a":1}`, "/v1/query#015abd7f5cc57a2d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Key(req, []byte(tt.body)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	const (
+		apiKey = "secret-api-key"
+		cookie = "session=secret-cookie"
+		body   = `{"height":42}`
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != apiKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Set-Cookie", cookie)
+		w.Header().Add("Set-Cookie", "other=secret-cookie")
+		io.WriteString(w, body)
+		w.Header().Set("X-Checksum", "abc")
+	}))
+	dir := t.TempDir()
+
+	get := func(rec *Recorder, url string) (*http.Response, []byte, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Api-Key", apiKey)
+		resp, err := rec.Client().Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+		buf, err := io.ReadAll(resp.Body)
+		return resp, buf, err
+	}
+
+	// record
+	resp, buf, err := get(New(dir, ModeRecord), srv.URL+"/explorer/block/42?meta=1&limit=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != body || resp.Trailer.Get("X-Checksum") != "abc" {
+		t.Fatalf("record: unexpected reply %q trailer=%v", buf, resp.Trailer)
+	}
+	srv.Close()
+
+	// fixtures must not contain credentials
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one fixture, got %v (%v)", files, err)
+	}
+	fixture, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{apiKey, "secret-cookie"} {
+		if bytes.Contains(fixture, []byte(secret)) {
+			t.Errorf("fixture contains %q", secret)
+		}
+	}
+	it, err := readInteraction(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(it.Response.Header.Values("Set-Cookie")); n != 2 {
+		t.Errorf("fixture has %d Set-Cookie values, want 2", n)
+	}
+
+	// replay with a different host and query order
+	rec := New(dir, ModeReplay)
+	resp, buf, err = get(rec, "http://localhost:1/explorer/block/42?limit=1&meta=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(buf) != body {
+		t.Errorf("replay: got %d %q", resp.StatusCode, buf)
+	}
+	if got := resp.Trailer.Get("X-Checksum"); got != "abc" {
+		t.Errorf("replay: trailer X-Checksum=%q", got)
+	}
+
+	// unknown requests fail in replay mode
+	_, _, err = get(rec, "http://localhost:1/explorer/block/43")
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("expected ErrNotRecorded, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "/explorer/block/43") {
+		t.Errorf("error does not name the request: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Package fake implements an in-process TzPro API server for unit tests.
+// It serves explorer, table and token routes from fixtures seeded with SDK
+// types and can be extended with custom handlers.
+//
+//	srv := fake.NewServer()
+//	defer srv.Close()
+//	srv.AddBlocks(&index.Block{RowId: 1, Height: 1, Hash: hash})
+//	client := srv.Client()
+//	b, err := client.Block.GetHeight(ctx, 1, tzpro.NoQuery)
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzpro-go/tzpro"
+	"blockwatch.cc/tzpro-go/tzpro/index"
+	"blockwatch.cc/tzpro-go/tzpro/token"
+)
+
+// Server is a fake TzPro API server. All methods are safe for concurrent
+// use, fixtures may be added while clients are running.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.RWMutex
+	accounts map[string]*index.Account
+	blocks   []*index.Block // sorted by height
+	ops      []*index.Op
+	tokens   []*token.Token
+	tables   map[string]*table
+	handlers map[string]http.HandlerFunc
+	requests []string
+}
+
+// NewServer starts a fake server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		accounts: make(map[string]*index.Account),
+		tables:   make(map[string]*table),
+		handlers: make(map[string]http.HandlerFunc),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns an SDK client connected to the server.
+func (s *Server) Client() *tzpro.Client {
+	return tzpro.NewClient(s.URL, s.Server.Client())
+}
+
+// Handle installs a handler for an exact request path, e.g. to serve a
+// route the fake does not implement or to inject failures. Custom handlers
+// take precedence over built-in routes.
+func (s *Server) Handle(path string, h http.HandlerFunc) {
+	s.mu.Lock()
+	s.handlers[path] = h
+	s.mu.Unlock()
+}
+
+// Requests returns the method and URL of all requests served so far.
+func (s *Server) Requests() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.requests...)
+}
+
+// AddAccounts adds accounts to the explorer and the account table.
+func (s *Server) AddAccounts(accs ...*index.Account) {
+	s.mu.Lock()
+	for _, a := range accs {
+		s.accounts[a.Address.String()] = a
+	}
+	s.mu.Unlock()
+	AddTable(s, "account", accs...)
+}
+
+// AddBlocks adds blocks to the explorer and the block table. The block
+// with the highest height is served as chain head.
+func (s *Server) AddBlocks(blocks ...*index.Block) {
+	s.mu.Lock()
+	s.blocks = append(s.blocks, blocks...)
+	sort.SliceStable(s.blocks, func(i, j int) bool { return s.blocks[i].Height < s.blocks[j].Height })
+	s.mu.Unlock()
+	AddTable(s, "block", blocks...)
+}
+
+// AddOps adds operations to the explorer and the op table.
+func (s *Server) AddOps(ops ...*index.Op) {
+	s.mu.Lock()
+	s.ops = append(s.ops, ops...)
+	s.mu.Unlock()
+	AddTable(s, "op", ops...)
+}
+
+// AddTokens adds tokens to the token API.
+func (s *Server) AddTokens(tokens ...*token.Token) {
+	s.mu.Lock()
+	s.tokens = append(s.tokens, tokens...)
+	s.mu.Unlock()
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+	h, ok := s.handlers[r.URL.Path]
+	s.mu.Unlock()
+	if ok {
+		h(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(p) == 2 && p[0] == "explorer" && p[1] == "status":
+		s.serveStatus(w)
+	case len(p) == 2 && p[0] == "explorer" && p[1] == "tip":
+		s.serveTip(w)
+	case len(p) >= 3 && p[0] == "explorer" && p[1] == "account":
+		s.serveAccount(w, p[2:])
+	case len(p) >= 3 && p[0] == "explorer" && p[1] == "block":
+		s.serveBlock(w, p[2:])
+	case len(p) == 3 && p[0] == "explorer" && p[1] == "op":
+		s.serveOp(w, p[2])
+	case len(p) == 2 && p[0] == "tables":
+		s.serveTable(w, r, p[1])
+	case len(p) >= 2 && p[0] == "v1" && p[1] == "tokens":
+		s.serveTokens(w, r, p[2:])
+	default:
+		writeError(w, http.StatusNotFound, "no such route")
+	}
+}
+
+func (s *Server) head() *index.Block {
+	if len(s.blocks) == 0 {
+		return nil
+	}
+	return s.blocks[len(s.blocks)-1]
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter) {
+	st := index.Status{Status: "synced", Progress: 1}
+	if b := s.head(); b != nil {
+		st.Blocks, st.Indexed, st.Finalized = b.Height, b.Height, b.Height
+	}
+	writeJSON(w, st)
+}
+
+func (s *Server) serveTip(w http.ResponseWriter) {
+	b := s.head()
+	if b == nil {
+		writeError(w, http.StatusNotFound, "no blocks")
+		return
+	}
+	writeJSON(w, index.Tip{
+		Hash:      b.Hash,
+		Height:    b.Height,
+		Cycle:     b.Cycle,
+		Timestamp: b.Timestamp,
+		Protocol:  b.Protocol,
+	})
+}
+
+func (s *Server) serveAccount(w http.ResponseWriter, p []string) {
+	a, ok := s.accounts[p[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such account")
+		return
+	}
+	switch {
+	case len(p) == 1:
+		writeJSON(w, a)
+	case len(p) == 2 && p[1] == "operations":
+		ops := make([]*index.Op, 0)
+		for _, op := range s.ops {
+			if op.Sender.Equal(a.Address) || op.Receiver.Equal(a.Address) {
+				ops = append(ops, op)
+			}
+		}
+		writeJSON(w, ops)
+	default:
+		writeError(w, http.StatusNotFound, "no such route")
+	}
+}
+
+func (s *Server) findBlock(id string) *index.Block {
+	if id == "head" {
+		return s.head()
+	}
+	if h, err := strconv.ParseInt(id, 10, 64); err == nil {
+		for _, b := range s.blocks {
+			if b.Height == h {
+				return b
+			}
+		}
+		return nil
+	}
+	for _, b := range s.blocks {
+		if b.Hash.String() == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveBlock(w http.ResponseWriter, p []string) {
+	b := s.findBlock(p[0])
+	if b == nil {
+		writeError(w, http.StatusNotFound, "no such block")
+		return
+	}
+	switch {
+	case len(p) == 1:
+		writeJSON(w, b)
+	case len(p) == 2 && p[1] == "operations":
+		ops := make([]*index.Op, 0)
+		for _, op := range s.ops {
+			if op.Height == b.Height {
+				ops = append(ops, op)
+			}
+		}
+		writeJSON(w, ops)
+	default:
+		writeError(w, http.StatusNotFound, "no such route")
+	}
+}
+
+func (s *Server) serveOp(w http.ResponseWriter, hash string) {
+	ops := make([]*index.Op, 0)
+	for _, op := range s.ops {
+		if op.Hash.String() == hash {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		writeError(w, http.StatusNotFound, "no such operation")
+		return
+	}
+	writeJSON(w, ops)
+}
+
+func (s *Server) serveTokens(w http.ResponseWriter, r *http.Request, p []string) {
+	if len(p) == 0 {
+		list := s.tokens
+		off, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if off > len(list) {
+			off = len(list)
+		}
+		list = list[off:]
+		if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n >= 0 && n < len(list) {
+			list = list[:n]
+		}
+		writeJSON(w, list)
+		return
+	}
+	addr, err := tezos.ParseToken(p[0])
+	if err != nil || len(p) > 1 {
+		writeError(w, http.StatusBadRequest, "invalid token address")
+		return
+	}
+	for _, t := range s.tokens {
+		if t.Address().String() == addr.String() {
+			writeJSON(w, t)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "no such token")
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf)
+}
+
+// writeError sends an error in the same format as the API.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"errors":[{"code":%d,"status":%d,"message":%q}]}`, status, status, msg)
+}
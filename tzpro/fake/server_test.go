@@ -0,0 +1,274 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"blockwatch.cc/tzgo/tezos"
+	"blockwatch.cc/tzpro-go/tzpro"
+	"blockwatch.cc/tzpro-go/tzpro/index"
+)
+
+var (
+	testSender   = tezos.MustParseAddress("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx")
+	testReceiver = tezos.MustParseAddress("tz1gfArv665EUkSg2ojMBzcbfwuPxAvqPvjo")
+	testTime     = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+)
+
+func testBlockHash(height int64) index.BlockHash {
+	var buf [32]byte
+	buf[0], buf[1] = byte(height>>8), byte(height)
+	return index.BlockHash(buf)
+}
+
+func testOpHash(n int) index.OpHash {
+	var buf [32]byte
+	buf[0] = byte(n)
+	return index.OpHash(buf)
+}
+
+// newTestServer seeds blocks 1..5 and two transactions in every block.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+	for h := int64(5); h > 0; h-- {
+		srv.AddBlocks(&index.Block{
+			RowId:     uint64(h),
+			Hash:      testBlockHash(h),
+			Height:    h,
+			Cycle:     h / 2,
+			Timestamp: testTime.Add(time.Duration(h) * time.Minute),
+		})
+	}
+	var ops []*index.Op
+	for h := int64(1); h <= 5; h++ {
+		for n := 0; n < 2; n++ {
+			id := uint64(h*2) + uint64(n)
+			status := tezos.OpStatusApplied
+			if n > 0 {
+				status = tezos.OpStatusFailed
+			}
+			ops = append(ops, &index.Op{
+				Id:        id,
+				Type:      index.OpTypeTransaction,
+				Hash:      testOpHash(int(id)),
+				Height:    h,
+				Timestamp: testTime.Add(time.Duration(h) * time.Minute),
+				Volume:    float64(id),
+				Status:    status,
+				IsSuccess: n == 0,
+				Sender:    testSender,
+				Receiver:  testReceiver,
+				Block:     testBlockHash(h),
+			})
+		}
+	}
+	srv.AddOps(ops...)
+	return srv
+}
+
+func TestServerExplorer(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := srv.Client()
+
+	head, err := c.Block.GetHead(ctx, tzpro.NoQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Height != 5 || !head.Hash.Equal(testBlockHash(5)) {
+		t.Errorf("head %d %s, want the highest block", head.Height, head.Hash)
+	}
+	b, err := c.Block.GetHeight(ctx, 3, tzpro.NoQuery)
+	if err != nil || b.Height != 3 || !b.Timestamp.Equal(testTime.Add(3*time.Minute)) {
+		t.Errorf("block 3: %+v %v", b, err)
+	}
+	b, err = c.Block.GetHash(ctx, testBlockHash(2), tzpro.NoQuery)
+	if err != nil || b.Height != 2 {
+		t.Errorf("block by hash: %+v %v", b, err)
+	}
+	ops, err := c.Block.ListOpsHeight(ctx, 4, tzpro.NoQuery)
+	if err != nil || len(ops) != 2 || ops[0].Height != 4 || ops[1].Height != 4 {
+		t.Errorf("block ops: %v %v", ops, err)
+	}
+	ops, err = c.Op.Get(ctx, testOpHash(7), tzpro.NoQuery)
+	if err != nil || len(ops) != 1 || ops[0].Id != 7 || !ops[0].Sender.Equal(testSender) {
+		t.Errorf("op: %v %v", ops, err)
+	}
+
+	// missing objects and routes return API errors
+	for name, fn := range map[string]func() error{
+		"block": func() error { _, err := c.Block.GetHeight(ctx, 6, tzpro.NoQuery); return err },
+		"hash":  func() error { _, err := c.Block.GetHash(ctx, testBlockHash(9), tzpro.NoQuery); return err },
+		"op":    func() error { _, err := c.Op.Get(ctx, testOpHash(99), tzpro.NoQuery); return err },
+		"account": func() error {
+			_, err := c.Account.Get(ctx, testSender, tzpro.NoQuery)
+			return err
+		},
+	} {
+		err := fn()
+		if !errors.Is(err, tzpro.ErrNotFound) {
+			t.Errorf("%s: got %v, want ErrNotFound", name, err)
+		}
+		if e, ok := tzpro.IsErrHttp(err); !ok || e.StatusCode() != http.StatusNotFound {
+			t.Errorf("%s: got %#v, want HTTP 404", name, err)
+		}
+	}
+
+	if reqs := srv.Requests(); len(reqs) != 9 || reqs[0] != "GET /explorer/block/head" {
+		t.Errorf("requests %q", reqs)
+	}
+}
+
+func TestServerEmpty(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	_, err := srv.Client().Block.GetHead(context.Background(), tzpro.NoQuery)
+	if !errors.Is(err, tzpro.ErrNotFound) {
+		t.Errorf("head without blocks: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestServerTableFilter(t *testing.T) {
+	ctx := context.Background()
+	c := newTestServer(t).Client()
+
+	tests := []struct {
+		name  string
+		query func(q *index.OpQuery) *index.OpQuery
+		want  string
+	}{
+		{"all", func(q *index.OpQuery) *index.OpQuery { return q }, "2 3 4 5 6 7 8 9 10 11"},
+		{"eq", func(q *index.OpQuery) *index.OpQuery { return q.AndEqual("height", 3) }, "6 7"},
+		{"ne", func(q *index.OpQuery) *index.OpQuery { return q.AndNotEqual("height", 1) }, "4 5 6 7 8 9 10 11"},
+		{"gt", func(q *index.OpQuery) *index.OpQuery { return q.AndGt("volume", 9) }, "10 11"},
+		{"lte", func(q *index.OpQuery) *index.OpQuery { return q.AndLte("id", 3) }, "2 3"},
+		{"in", func(q *index.OpQuery) *index.OpQuery { return q.AndIn("id", 2, 5, 11) }, "2 5 11"},
+		{"nin", func(q *index.OpQuery) *index.OpQuery { return q.AndNotIn("height", 1, 2, 3) }, "8 9 10 11"},
+		{"range", func(q *index.OpQuery) *index.OpQuery { return q.AndRange("height", 2, 3) }, "4 5 6 7"},
+		{"bool", func(q *index.OpQuery) *index.OpQuery { return q.AndEqual("is_success", false) }, "3 5 7 9 11"},
+		{"time", func(q *index.OpQuery) *index.OpQuery {
+			return q.AndGte("time", testTime.Add(4*time.Minute).Format(time.RFC3339))
+		}, "8 9 10 11"},
+		{"hash", func(q *index.OpQuery) *index.OpQuery { return q.AndEqual("block", testBlockHash(2)) }, "4 5"},
+		{"combined", func(q *index.OpQuery) *index.OpQuery {
+			return q.AndGte("height", 2).AndEqual("is_success", true).WithLimit(2)
+		}, "4 6"},
+		{"desc", func(q *index.OpQuery) *index.OpQuery { return q.AndLt("height", 3).Desc() }, "5 4 3 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.query(c.Op.NewQuery()).Run(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, op := range res.Rows() {
+				ids = append(ids, fmt.Sprint(op.Id))
+			}
+			if got := strings.Join(ids, " "); got != tt.want {
+				t.Errorf("rows %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerTableColumns(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c := srv.Client()
+
+	res, err := c.Block.NewQuery().WithColumns("height", "hash").AndEqual("height", 2).Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Len() != 1 {
+		t.Fatalf("got %d rows", res.Len())
+	}
+	if b := res.Rows()[0]; b.Height != 2 || !b.Hash.Equal(testBlockHash(2)) || b.RowId != 0 {
+		t.Errorf("row %+v, want only height and hash", b)
+	}
+
+	_, err = c.Block.NewQuery().WithColumns("height", "unknown").Run(ctx)
+	if !errors.Is(err, tzpro.ErrBadRequest) {
+		t.Errorf("unknown column: got %v, want ErrBadRequest", err)
+	}
+	_, err = c.Block.NewQuery().AndFilter(tzpro.FilterModeRegexp, "hash", "^BL").Run(ctx)
+	if !errors.Is(err, tzpro.ErrBadRequest) {
+		t.Errorf("unsupported filter: got %v, want ErrBadRequest", err)
+	}
+	_, err = c.Block.NewQuery().WithFormat(tzpro.FormatCSV).Run(ctx)
+	if !errors.Is(err, tzpro.ErrBadRequest) {
+		t.Errorf("csv format: got %v, want ErrBadRequest", err)
+	}
+
+	resp, err := srv.Server.Client().Get(srv.URL + "/tables/unknown.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown table: status %d", resp.StatusCode)
+	}
+}
+
+func TestServerTablePaging(t *testing.T) {
+	ctx := context.Background()
+	c := newTestServer(t).Client()
+
+	tests := []struct {
+		name  string
+		query *index.OpQuery
+		pages []string
+	}{
+		{"asc", c.Op.NewQuery().WithLimit(4), []string{"2 3 4 5", "6 7 8 9", "10 11"}},
+		{"desc", c.Op.NewQuery().WithLimit(4).Desc(), []string{"11 10 9 8", "7 6 5 4", "3 2"}},
+		{"filter", c.Op.NewQuery().AndEqual("is_success", true).WithLimit(2), []string{"2 4", "6 8", "10"}},
+		{"cursor", c.Op.NewQuery().WithCursor(7).WithLimit(3), []string{"8 9 10", "11"}},
+		{"desc_cursor", c.Op.NewQuery().WithCursor(5).Desc().WithLimit(3), []string{"4 3 2"}},
+		{"max_rows", c.Op.NewQuery().WithLimit(3).WithMaxRows(5), []string{"2 3 4", "5 6"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages []string
+			p := tt.query.Pages()
+			for p.Next(ctx) {
+				var ids []string
+				for _, op := range p.Rows() {
+					ids = append(ids, fmt.Sprint(op.Id))
+				}
+				pages = append(pages, strings.Join(ids, " "))
+			}
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprintf("%q", pages) != fmt.Sprintf("%q", tt.pages) {
+				t.Errorf("pages %q, want %q", pages, tt.pages)
+			}
+		})
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Handle("/explorer/block/head", func(w http.ResponseWriter, _ *http.Request) {
+		writeError(w, http.StatusServiceUnavailable, "maintenance")
+	})
+	_, err := srv.Client().WithRetry(0, 0).Block.GetHead(context.Background(), tzpro.NoQuery)
+	if !errors.Is(err, tzpro.ErrServerUnavailable) {
+		t.Errorf("custom handler: got %v, want ErrServerUnavailable", err)
+	}
+	// other routes keep working
+	if _, err := srv.Client().Block.GetHeight(context.Background(), 1, tzpro.NoQuery); err != nil {
+		t.Error(err)
+	}
+}
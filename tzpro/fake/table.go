@@ -0,0 +1,246 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package fake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"blockwatch.cc/tzpro-go/internal/client"
+)
+
+// table holds encoded rows of a table fixture sorted by cursor.
+type table struct {
+	columns []string
+	index   map[string]int
+	rows    []tableRow
+}
+
+type tableRow struct {
+	cursor uint64
+	values []json.RawMessage
+}
+
+// AddTable adds rows to table name. Rows are structs or struct pointers
+// from the SDK (e.g. index.Flow for table "flow") and are served in the
+// column array format of the tables API. The first struct field is used
+// as cursor when it is an uint64. AddTable panics when rows cannot be
+// encoded.
+func AddTable[T any](s *Server, name string, rows ...T) {
+	if len(rows) == 0 {
+		return
+	}
+	columns, err := client.Columns(rows[0])
+	if err != nil {
+		panic(fmt.Errorf("fake: table %s: %w", name, err))
+	}
+	encoded := make([]tableRow, len(rows))
+	for i, r := range rows {
+		values, err := client.EncodeRow(r, nil)
+		if err != nil {
+			panic(fmt.Errorf("fake: table %s row %d: %w", name, i, err))
+		}
+		encoded[i] = tableRow{cursor: rowCursor(r), values: values}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tables[name]
+	if !ok {
+		t = &table{columns: columns, index: make(map[string]int)}
+		for i, c := range columns {
+			t.index[c] = i
+		}
+		s.tables[name] = t
+	}
+	t.rows = append(t.rows, encoded...)
+	sort.SliceStable(t.rows, func(i, j int) bool { return t.rows[i].cursor < t.rows[j].cursor })
+}
+
+func rowCursor(row any) uint64 {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	if v.NumField() > 0 && v.Field(0).Kind() == reflect.Uint64 {
+		return v.Field(0).Uint()
+	}
+	return 0
+}
+
+func (s *Server) serveTable(w http.ResponseWriter, r *http.Request, file string) {
+	name, format, _ := strings.Cut(file, ".")
+	if format != "json" {
+		writeError(w, http.StatusBadRequest, "unsupported format "+strconv.Quote(format))
+		return
+	}
+	t, ok := s.tables[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such table")
+		return
+	}
+	q := r.URL.Query()
+
+	// output columns
+	cols := make([]int, 0, len(t.columns))
+	if c := q.Get("columns"); c != "" {
+		for _, n := range strings.Split(c, ",") {
+			i, ok := t.index[n]
+			if !ok {
+				writeError(w, http.StatusBadRequest, "unknown column "+strconv.Quote(n))
+				return
+			}
+			cols = append(cols, i)
+		}
+	} else {
+		for i := range t.columns {
+			cols = append(cols, i)
+		}
+	}
+
+	// filters
+	var filters []filter
+	for key, vals := range q {
+		col, mode, ok := strings.Cut(key, ".")
+		if !ok {
+			continue
+		}
+		i, ok := t.index[col]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "unknown column "+strconv.Quote(col))
+			return
+		}
+		f := filter{col: i, mode: mode, vals: strings.Split(vals[0], ",")}
+		if err := f.check(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filters = append(filters, f)
+	}
+
+	desc := q.Get("order") == "desc"
+	cursor, _ := strconv.ParseUint(q.Get("cursor"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	out := make([][]json.RawMessage, 0)
+	for i := range t.rows {
+		row := t.rows[i]
+		if desc {
+			row = t.rows[len(t.rows)-1-i]
+		}
+		if cursor > 0 && (!desc && row.cursor <= cursor || desc && row.cursor >= cursor) {
+			continue
+		}
+		if !matchAll(filters, row.values) {
+			continue
+		}
+		res := make([]json.RawMessage, len(cols))
+		for j, c := range cols {
+			res[j] = row.values[c]
+		}
+		out = append(out, res)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	writeJSON(w, out)
+}
+
+// filter matches encoded column values against a query filter.
+type filter struct {
+	col  int
+	mode string
+	vals []string
+}
+
+func (f filter) check() error {
+	switch f.mode {
+	case "eq", "ne", "gt", "gte", "lt", "lte", "in", "nin":
+	case "rg":
+		if len(f.vals) != 2 {
+			return fmt.Errorf("range filter requires two values")
+		}
+	default:
+		return fmt.Errorf("unsupported filter mode %q", f.mode)
+	}
+	return nil
+}
+
+func matchAll(filters []filter, values []json.RawMessage) bool {
+	for _, f := range filters {
+		if !f.match(values[f.col]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filter) match(v json.RawMessage) bool {
+	switch f.mode {
+	case "eq":
+		return compare(v, f.vals[0]) == 0
+	case "ne":
+		return compare(v, f.vals[0]) != 0
+	case "gt":
+		return compare(v, f.vals[0]) > 0
+	case "gte":
+		return compare(v, f.vals[0]) >= 0
+	case "lt":
+		return compare(v, f.vals[0]) < 0
+	case "lte":
+		return compare(v, f.vals[0]) <= 0
+	case "rg":
+		return compare(v, f.vals[0]) >= 0 && compare(v, f.vals[1]) <= 0
+	case "in", "nin":
+		for _, val := range f.vals {
+			if compare(v, val) == 0 {
+				return f.mode == "in"
+			}
+		}
+		return f.mode == "nin"
+	}
+	return false
+}
+
+// compare compares an encoded column value with a query string. Numbers
+// compare numerically, times in RFC3339 compare to millisecond timestamps
+// and booleans to 0/1. Anything else compares as string.
+func compare(v json.RawMessage, s string) int {
+	if len(v) > 0 && v[0] != '"' && v[0] != '[' && v[0] != '{' && !bytes.Equal(v, []byte("null")) {
+		a, err := strconv.ParseFloat(string(v), 64)
+		if err == nil {
+			var b float64
+			switch {
+			case s == "true":
+				b = 1
+			case s == "false":
+				b = 0
+			default:
+				if b, err = strconv.ParseFloat(s, 64); err != nil {
+					tm, err := time.Parse(time.RFC3339, s)
+					if err != nil {
+						return strings.Compare(string(v), s)
+					}
+					b = float64(tm.UnixMilli())
+				}
+			}
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		}
+	}
+	var str string
+	if json.Unmarshal(v, &str) != nil {
+		str = string(v)
+	}
+	return strings.Compare(str, s)
+}
@@ -63,7 +63,7 @@ func (c *contractClient) NewBigmapQuery() *BigmapQuery {
 func (c *contractClient) GetBigmap(ctx context.Context, id int64, params Query) (*Bigmap, error) {
 	b := &Bigmap{}
 	u := params.WithPath(fmt.Sprintf("/explorer/bigmap/%d", id)).Url()
-	if err := c.client.Get(ctx, u, nil, b); err != nil {
+	if err := c.client.GetShared(ctx, u, nil, b); err != nil {
 		return nil, err
 	}
 	return b, nil
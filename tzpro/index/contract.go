@@ -116,7 +116,7 @@ func (c *contractClient) Get(ctx context.Context, addr Address, params Query) (*
 func (c *contractClient) GetScript(ctx context.Context, addr Address, params Query) (*ContractScript, error) {
 	cc := &ContractScript{}
 	u := params.WithPath(fmt.Sprintf("/explorer/contract/%s/script", addr)).Url()
-	if err := c.client.Get(ctx, u, nil, cc); err != nil {
+	if err := c.client.GetShared(ctx, u, nil, cc); err != nil {
 		return nil, err
 	}
 	return cc, nil
@@ -5,8 +5,14 @@ package index
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 
 	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzpro-go/internal/client"
+	"blockwatch.cc/tzpro-go/internal/util"
+	lru "github.com/hashicorp/golang-lru/v2"
 )
 
 func (c *opClient) loadScript(ctx context.Context, addr Address) (*ContractScript, error) {
@@ -33,6 +39,146 @@ func (c *opClient) loadScript(ctx context.Context, addr Address) (*ContractScrip
 	return script, nil
 }
 
+// scriptCacheVersion is bumped whenever the on-disk format changes. Files
+// written in another version are ignored and replaced.
+const scriptCacheVersion = 2
+
+var _ client.ScriptCache = (*FileScriptCache)(nil)
+
+// FileScriptCache is a persistent script cache. Stripped contract scripts
+// are stored as one file per address below a directory and kept in memory
+// after first use. Use it with Client.UseScriptCache to avoid refetching
+// scripts after restarts.
+type FileScriptCache struct {
+	dir   string
+	cache *lru.TwoQueueCache[Address, any]
+}
+
+// NewFileScriptCache creates a script cache in dir that keeps up to size
+// scripts in memory.
+func NewFileScriptCache(dir string, size int) (*FileScriptCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if size < 2 {
+		size = client.DefaultCacheSize
+	}
+	cache, err := lru.New2Q[Address, any](size)
+	if err != nil {
+		return nil, err
+	}
+	return &FileScriptCache{dir: dir, cache: cache}, nil
+}
+
+func (c *FileScriptCache) path(addr Address) string {
+	return filepath.Join(c.dir, addr.String()+".json")
+}
+
+func (c *FileScriptCache) Get(addr Address) (any, bool) {
+	if val, ok := c.cache.Get(addr); ok {
+		return val, true
+	}
+	buf, err := os.ReadFile(c.path(addr))
+	if err != nil {
+		return nil, false
+	}
+	var rec scriptCacheRecord
+	if err := json.Unmarshal(buf, &rec); err != nil || rec.Version != scriptCacheVersion || !rec.Address.Equal(addr) {
+		return nil, false
+	}
+	script := rec.Script()
+	c.cache.Add(addr, script)
+	return script, true
+}
+
+func (c *FileScriptCache) Add(addr Address, val any) {
+	c.cache.Add(addr, val)
+	script, ok := val.(*ContractScript)
+	if !ok || script.Script == nil {
+		return
+	}
+	buf, err := json.Marshal(newScriptCacheRecord(addr, script))
+	if err != nil {
+		return
+	}
+	_ = util.WriteFileAtomic(c.path(addr), buf)
+}
+
+// Remove drops a script from memory and disk.
+func (c *FileScriptCache) Remove(addr Address) {
+	c.cache.Remove(addr)
+	_ = os.Remove(c.path(addr))
+}
+
+// scriptCacheRecord is the on-disk format of a stripped contract script.
+// Types and views are stored as Micheline primitives because their JSON
+// encoding as typedef cannot be decoded.
+type scriptCacheRecord struct {
+	Version     int              `json:"version"`
+	Address     Address          `json:"address"`
+	Param       Prim             `json:"param"`
+	Storage     Prim             `json:"storage"`
+	Data        Prim             `json:"data"`
+	StorageType Typedef          `json:"storage_type"`
+	Entrypoints Entrypoints      `json:"entrypoints"`
+	Views       map[string]Prim  `json:"views,omitempty"`
+	BigmapNames map[string]int64 `json:"bigmaps,omitempty"`
+	BigmapTypes map[int64]Prim   `json:"bigmap_types,omitempty"`
+}
+
+func newScriptCacheRecord(addr Address, s *ContractScript) *scriptCacheRecord {
+	rec := &scriptCacheRecord{
+		Version:     scriptCacheVersion,
+		Address:     addr,
+		Param:       s.Script.Code.Param,
+		Storage:     s.Script.Code.Storage,
+		Data:        s.Script.Storage,
+		StorageType: s.StorageType,
+		Entrypoints: s.Entrypoints,
+		Views:       make(map[string]Prim, len(s.Views)),
+		BigmapNames: s.BigmapNames,
+		BigmapTypes: make(map[int64]Prim, len(s.BigmapTypesById)),
+	}
+	for n, v := range s.Views {
+		// view code is stripped like contract code
+		rec.Views[n] = micheline.NewCode(micheline.K_VIEW, micheline.NewString(v.Name), v.Param.Prim, v.Retval.Prim, micheline.NewSeq())
+	}
+	for id, typ := range s.BigmapTypesById {
+		rec.BigmapTypes[id] = typ.Prim
+	}
+	return rec
+}
+
+func (r *scriptCacheRecord) Script() *ContractScript {
+	s := &ContractScript{
+		Script: &Script{
+			Code: micheline.Code{
+				Param:   r.Param,
+				Storage: r.Storage,
+			},
+			Storage: r.Data,
+		},
+		StorageType:     r.StorageType,
+		Entrypoints:     r.Entrypoints,
+		Views:           make(Views, len(r.Views)),
+		BigmapNames:     r.BigmapNames,
+		BigmapTypes:     make(map[string]Type, len(r.BigmapNames)),
+		BigmapTypesById: make(map[int64]Type, len(r.BigmapTypes)),
+	}
+	for n, p := range r.Views {
+		s.Views[n] = micheline.NewView(p)
+	}
+	for id, p := range r.BigmapTypes {
+		s.BigmapTypesById[id] = NewType(p)
+	}
+	for n, id := range r.BigmapNames {
+		if typ, ok := s.BigmapTypesById[id]; ok {
+			s.BigmapTypes[n] = typ
+		}
+	}
+	return s
+}
+
 // func (c *Client) AddCachedScript(addr Address, script *micheline.Script) {
 // 	if !addr.IsValid() || script == nil || c.cache == nil {
 // 		return
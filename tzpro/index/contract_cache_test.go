@@ -0,0 +1,223 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"blockwatch.cc/tzgo/micheline"
+	"blockwatch.cc/tzgo/tezos"
+)
+
+// testScript is a token contract with two entrypoints, a ledger bigmap
+// with id 17 and one view.
+const testScript = `{
+	"code": [
+		{"prim":"parameter","args":[{"prim":"or","args":[
+			{"prim":"pair","args":[{"prim":"address","annots":["%to"]},{"prim":"nat","annots":["%value"]}],"annots":["%transfer"]},
+			{"prim":"nat","annots":["%burn"]}
+		]}]},
+		{"prim":"storage","args":[{"prim":"pair","args":[
+			{"prim":"big_map","args":[{"prim":"address"},{"prim":"nat"}],"annots":["%ledger"]},
+			{"prim":"nat","annots":["%total"]}
+		]}]},
+		{"prim":"code","args":[[{"prim":"CDR"},{"prim":"NIL","args":[{"prim":"operation"}]},{"prim":"PAIR"}]]},
+		{"prim":"view","args":[{"string":"total"},{"prim":"unit"},{"prim":"nat"},[{"prim":"CDR"},{"prim":"CDR"}]]}
+	],
+	"storage": {"prim":"Pair","args":[{"int":"17"},{"int":"1000"}]}
+}`
+
+func newTestScript(t *testing.T) *ContractScript {
+	t.Helper()
+	var script Script
+	if err := json.Unmarshal([]byte(testScript), &script); err != nil {
+		t.Fatal(err)
+	}
+	eps, err := script.Entrypoints(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	views, err := script.Views(true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &ContractScript{
+		Script:          &script,
+		StorageType:     script.StorageType().Typedef(""),
+		Entrypoints:     eps,
+		Views:           views,
+		BigmapNames:     script.Bigmaps(),
+		BigmapTypes:     script.BigmapTypes(),
+		BigmapTypesById: make(map[int64]Type),
+	}
+	for n, v := range s.BigmapTypes {
+		s.BigmapTypesById[s.BigmapNames[n]] = v
+	}
+	// stored scripts are stripped like in loadScript
+	s.Script.Code.Code = micheline.Prim{}
+	s.Script.Code.View = micheline.Prim{}
+	return s
+}
+
+func jsonEqual(t *testing.T, name string, got, want any) {
+	t.Helper()
+	g, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(g) != string(w) {
+		t.Errorf("%s mismatch\n got %s\nwant %s", name, g, w)
+	}
+}
+
+func TestFileScriptCacheRoundTrip(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1Puc9St8wdNoGtLiD2WXaHbWU7styaxYhD")
+	want := newTestScript(t)
+	if len(want.Entrypoints) != 2 || len(want.Views) != 1 || want.BigmapNames["ledger"] != 17 {
+		t.Fatalf("unexpected test script: %d entrypoints, %d views, bigmaps %v", len(want.Entrypoints), len(want.Views), want.BigmapNames)
+	}
+
+	dir := t.TempDir()
+	c, err := NewFileScriptCache(dir, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add(addr, want)
+
+	// a fresh cache has to load the script from disk
+	c, err = NewFileScriptCache(dir, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, ok := c.Get(addr)
+	if !ok {
+		t.Fatal("script not found on disk")
+	}
+	got := val.(*ContractScript)
+
+	jsonEqual(t, "entrypoints", got.Entrypoints, want.Entrypoints)
+	jsonEqual(t, "storage type", got.StorageType, want.StorageType)
+	jsonEqual(t, "bigmap names", got.BigmapNames, want.BigmapNames)
+	if !got.Script.Code.Param.IsEqualWithAnno(want.Script.Code.Param) ||
+		!got.Script.Code.Storage.IsEqualWithAnno(want.Script.Code.Storage) ||
+		!got.Script.Storage.IsEqual(want.Script.Storage) {
+		t.Errorf("script mismatch")
+	}
+	if len(got.Views) != len(want.Views) {
+		t.Fatalf("got %d views, want %d", len(got.Views), len(want.Views))
+	}
+	for n, v := range want.Views {
+		if !got.Views[n].IsEqual(v) || got.Views[n].Name != v.Name {
+			t.Errorf("view %s mismatch: got %+v", n, got.Views[n])
+		}
+	}
+	if len(got.BigmapTypesById) != len(want.BigmapTypesById) {
+		t.Fatalf("got %d bigmap types, want %d", len(got.BigmapTypesById), len(want.BigmapTypesById))
+	}
+	for id, typ := range want.BigmapTypesById {
+		if !got.BigmapTypesById[id].IsEqualWithAnno(typ) {
+			t.Errorf("bigmap %d type %s, want %s", id, got.BigmapTypesById[id].Dump(), typ.Dump())
+		}
+		if !got.BigmapTypes["ledger"].IsEqualWithAnno(typ) {
+			t.Errorf("bigmap ledger type not restored")
+		}
+	}
+
+	// decoded types are usable for op decoding
+	param, store, eps, bigmaps := got.Types()
+	if !param.IsValid() || !store.IsValid() || len(eps) != 2 || !bigmaps[17].IsValid() {
+		t.Errorf("cached script does not yield types")
+	}
+}
+
+func TestFileScriptCacheInvalid(t *testing.T) {
+	addr := tezos.MustParseAddress("KT1Puc9St8wdNoGtLiD2WXaHbWU7styaxYhD")
+	other := tezos.MustParseAddress("KT1RJ6PbjHpwc3M5rw5s2Nbmefwbuwbdxton")
+	script := newTestScript(t)
+
+	tests := []struct {
+		name   string
+		modify func(rec map[string]any) []byte
+	}{
+		{
+			name: "stale_version",
+			modify: func(rec map[string]any) []byte {
+				rec["version"] = scriptCacheVersion - 1
+				buf, _ := json.Marshal(rec)
+				return buf
+			},
+		},
+		{
+			name: "future_version",
+			modify: func(rec map[string]any) []byte {
+				rec["version"] = scriptCacheVersion + 1
+				buf, _ := json.Marshal(rec)
+				return buf
+			},
+		},
+		{
+			name: "other_address",
+			modify: func(rec map[string]any) []byte {
+				rec["address"] = other.String()
+				buf, _ := json.Marshal(rec)
+				return buf
+			},
+		},
+		{
+			name: "truncated",
+			modify: func(rec map[string]any) []byte {
+				buf, _ := json.Marshal(rec)
+				return buf[:len(buf)/2]
+			},
+		},
+		{
+			name: "garbage",
+			modify: func(map[string]any) []byte {
+				return []byte{0x1f, 0x8b, 0x08, 0x00}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c, err := NewFileScriptCache(dir, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Add(addr, script)
+			buf, err := os.ReadFile(c.path(addr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var rec map[string]any
+			if err := json.Unmarshal(buf, &rec); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(c.path(addr), tt.modify(rec), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err = NewFileScriptCache(dir, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := c.Get(addr); ok {
+				t.Fatal("invalid cache file was used")
+			}
+
+			// the next fetch replaces the file
+			c.Add(addr, script)
+			c, _ = NewFileScriptCache(dir, 16)
+			if _, ok := c.Get(addr); !ok {
+				t.Errorf("rewritten cache file not loaded")
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"blockwatch.cc/tzpro-go/internal/client"
+)
+
+var (
+	DefaultFollowInterval = 5 * time.Second
+	DefaultFollowDepth    = 64
+
+	// ErrReorgTooDeep is returned when a reorg goes past the oldest block
+	// a follower remembers. The follower must be restarted from a block
+	// before the reorg.
+	ErrReorgTooDeep = errors.New("follower: reorg deeper than history")
+)
+
+// FollowEventType is the kind of a follower event.
+type FollowEventType byte
+
+const (
+	FollowBlock    FollowEventType = iota // block appended to the chain
+	FollowRollback                        // block removed by a reorg
+)
+
+func (t FollowEventType) String() string {
+	switch t {
+	case FollowBlock:
+		return "block"
+	case FollowRollback:
+		return "rollback"
+	default:
+		return "invalid"
+	}
+}
+
+// FollowEvent is emitted by a Follower for every block that is added to or
+// removed from the main chain. Block and Ops are only set for new blocks,
+// rollbacks carry the id of the removed block.
+type FollowEvent struct {
+	Type  FollowEventType
+	Id    BlockId
+	Block *Block
+	Ops   OpList
+}
+
+// Follower follows the chain by polling the current head and walking
+// forward block by block. Parent hashes are checked for every block. When
+// a block does not link to the last known block, the follower emits
+// rollback events until it finds the common ancestor and continues on the
+// new branch from there.
+//
+//	f := index.NewFollower(client.Block).WithCheckpoint(last)
+//	err := f.Run(ctx, func(ctx context.Context, e *index.FollowEvent) error {
+//		switch e.Type {
+//		case index.FollowBlock:
+//			// process e.Block and e.Ops
+//		case index.FollowRollback:
+//			// revert state for e.Id
+//		}
+//		return save(f.Checkpoint())
+//	})
+type Follower struct {
+	api      BlockAPI
+	params   Query
+	interval time.Duration
+	depth    int
+	start    int64
+	noOps    bool
+
+	mu      sync.Mutex
+	history []BlockId // last accepted blocks, newest last
+}
+
+// NewFollower creates a follower that starts at the current head.
+func NewFollower(api BlockAPI) *Follower {
+	return &Follower{
+		api:      api,
+		params:   NewQuery(),
+		interval: DefaultFollowInterval,
+		depth:    DefaultFollowDepth,
+		start:    -1,
+	}
+}
+
+// WithInterval sets how often the chain head is polled.
+func (f *Follower) WithInterval(d time.Duration) *Follower {
+	f.interval = d
+	return f
+}
+
+// WithDepth sets how many recent blocks are kept for reorg detection.
+func (f *Follower) WithDepth(n int) *Follower {
+	if n > 0 {
+		f.depth = n
+	}
+	return f
+}
+
+// WithStart sets the first block height to emit when no checkpoint is set.
+func (f *Follower) WithStart(height int64) *Follower {
+	f.start = height
+	return f
+}
+
+// WithCheckpoint resumes after blocks that were processed before, oldest
+// first. The checkpoint is verified against the chain and rolled back when
+// it was orphaned in the meantime. Pass more than one block (see History)
+// to recover from reorgs that happened while the follower was down.
+func (f *Follower) WithCheckpoint(ids ...BlockId) *Follower {
+	f.mu.Lock()
+	f.history = append([]BlockId(nil), ids...)
+	f.mu.Unlock()
+	return f
+}
+
+// WithParams sets query params used to fetch blocks and operations.
+func (f *Follower) WithParams(params Query) *Follower {
+	f.params = params
+	return f
+}
+
+// WithoutOps disables loading block operations.
+func (f *Follower) WithoutOps() *Follower {
+	f.noOps = true
+	return f
+}
+
+// Checkpoint returns the id of the last block handled without error. It is
+// safe to call from event handlers and other goroutines.
+func (f *Follower) Checkpoint() BlockId {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if l := len(f.history); l > 0 {
+		return f.history[l-1]
+	}
+	return BlockId{}
+}
+
+// History returns the ids of recently handled blocks, oldest first.
+func (f *Follower) History() []BlockId {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]BlockId(nil), f.history...)
+}
+
+// Run follows the chain and calls fn for each event in chain order until
+// ctx is canceled or an error occurs. Errors from fn and the API are
+// returned, the follower may be resumed by calling Run again.
+func (f *Follower) Run(ctx context.Context, fn func(context.Context, *FollowEvent) error) error {
+	for {
+		if err := f.poll(ctx, fn); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+// poll catches up with the current head. It returns early without error
+// when the API is behind and waits for the next round.
+func (f *Follower) poll(ctx context.Context, fn func(context.Context, *FollowEvent) error) error {
+	head, err := f.api.GetHead(ctx, f.params)
+	if err != nil {
+		return err
+	}
+	tip, ok := f.tip()
+	if !ok {
+		b := head
+		if f.start >= 0 && f.start != head.Height {
+			if b, err = f.api.GetHeight(ctx, f.start, f.params); err != nil {
+				return err
+			}
+		}
+		if ok, err := f.accept(ctx, b, fn); !ok || err != nil {
+			return err
+		}
+		tip = b.BlockId()
+	}
+
+	for !tip.IsSameBlock(head) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		switch {
+		case tip.Height > head.Height:
+			// a head that differs from the block we know at its height is
+			// on another branch, e.g. after a reorg to a shorter chain
+			if id, ok := f.at(head.Height); ok && !id.IsSameBlock(head) {
+				if err := f.rollback(ctx, fn); err != nil {
+					return err
+				}
+				break
+			}
+			// head may lag behind (e.g. after endpoint failover), only roll
+			// back when our tip was replaced
+			b, err := f.api.GetHeight(ctx, tip.Height, f.params)
+			switch {
+			case errors.Is(err, client.ErrNotFound):
+				return nil
+			case err != nil:
+				return err
+			case tip.IsSameBlock(b):
+				return nil
+			}
+			if err := f.rollback(ctx, fn); err != nil {
+				return err
+			}
+		case tip.Height == head.Height:
+			// same height, different hash
+			if err := f.rollback(ctx, fn); err != nil {
+				return err
+			}
+		default:
+			next := head
+			if tip.Height+1 < head.Height {
+				next, err = f.api.GetHeight(ctx, tip.Height+1, f.params)
+				switch {
+				case errors.Is(err, client.ErrNotFound):
+					return nil
+				case err != nil:
+					return err
+				}
+			}
+			if tip.IsNextBlock(next) {
+				if ok, err := f.accept(ctx, next, fn); !ok || err != nil {
+					return err
+				}
+			} else if err := f.rollback(ctx, fn); err != nil {
+				return err
+			}
+		}
+		if tip, ok = f.tip(); !ok {
+			return ErrReorgTooDeep
+		}
+	}
+	return nil
+}
+
+func (f *Follower) tip() (BlockId, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if l := len(f.history); l > 0 {
+		return f.history[l-1], true
+	}
+	return BlockId{}, false
+}
+
+// at returns the id of the accepted block at height if it is still in
+// the history.
+func (f *Follower) at(height int64) (BlockId, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.history) - 1; i >= 0; i-- {
+		if f.history[i].Height == height {
+			return f.history[i], true
+		}
+	}
+	return BlockId{}, false
+}
+
+// accept loads operations for b and emits it as new block. It returns false
+// when the block could not be accepted yet.
+func (f *Follower) accept(ctx context.Context, b *Block, fn func(context.Context, *FollowEvent) error) (bool, error) {
+	e := &FollowEvent{
+		Type:  FollowBlock,
+		Id:    b.BlockId(),
+		Block: b,
+	}
+	if !f.noOps {
+		ops, err := f.api.ListOpsHeight(ctx, b.Height, f.params)
+		if err != nil {
+			return false, err
+		}
+		// operations from another branch mean a reorg happened in between,
+		// skip the block and let the next round detect it
+		for _, op := range ops {
+			if op.Block.IsValid() && !op.Block.Equal(b.Hash) {
+				return false, nil
+			}
+		}
+		e.Ops = ops
+	}
+	if err := fn(ctx, e); err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	f.history = append(f.history, e.Id)
+	if n := len(f.history) - f.depth; n > 0 {
+		f.history = append(f.history[:0], f.history[n:]...)
+	}
+	f.mu.Unlock()
+	return true, nil
+}
+
+// rollback emits a rollback event for the current tip and removes it.
+func (f *Follower) rollback(ctx context.Context, fn func(context.Context, *FollowEvent) error) error {
+	tip, ok := f.tip()
+	if !ok {
+		return ErrReorgTooDeep
+	}
+	if err := fn(ctx, &FollowEvent{Type: FollowRollback, Id: tip}); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.history = f.history[:len(f.history)-1]
+	f.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"blockwatch.cc/tzpro-go/internal/client"
+)
+
+// fakeChain is a scripted block source. Blocks are identified by height
+// and branch, a reorg replaces blocks on the main chain with blocks from
+// another branch.
+type fakeChain struct {
+	BlockAPI // unused methods panic
+
+	blocks map[int64]*Block // main chain by height
+	head   int64            // reported head height, 0 for the top block
+	top    int64
+	calls  int
+	onCall func(n int) // called with the call count before every API call
+}
+
+func testHash(height int64, branch byte) BlockHash {
+	var buf [32]byte
+	buf[0] = branch
+	buf[1], buf[2] = byte(height>>8), byte(height)
+	return BlockHash(buf)
+}
+
+func newFakeChain(n int64) *fakeChain {
+	c := &fakeChain{blocks: make(map[int64]*Block)}
+	c.extend(1, n, 'a')
+	return c
+}
+
+// extend replaces heights from..to with blocks of branch, linked to the
+// block below from.
+func (c *fakeChain) extend(from, to int64, branch byte) {
+	for h := from; h <= to; h++ {
+		parent := testHash(h-1, 'a')
+		if p, ok := c.blocks[h-1]; ok {
+			parent = p.Hash
+		}
+		c.blocks[h] = &Block{Height: h, Hash: testHash(h, branch), ParentHash: &parent}
+	}
+	for h := to + 1; h <= c.top; h++ {
+		delete(c.blocks, h)
+	}
+	c.top = to
+}
+
+func (c *fakeChain) call(ctx context.Context) error {
+	c.calls++
+	if c.onCall != nil {
+		c.onCall(c.calls)
+	}
+	return ctx.Err()
+}
+
+func (c *fakeChain) GetHead(ctx context.Context, _ Query) (*Block, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	h := c.head
+	if h == 0 {
+		h = c.top
+	}
+	return c.blocks[h], nil
+}
+
+func (c *fakeChain) GetHeight(ctx context.Context, height int64, _ Query) (*Block, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	b, ok := c.blocks[height]
+	if !ok {
+		return nil, client.ErrNotFound
+	}
+	return b, nil
+}
+
+func (c *fakeChain) ListOpsHeight(ctx context.Context, height int64, _ Query) (OpList, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	b, ok := c.blocks[height]
+	if !ok {
+		return nil, client.ErrNotFound
+	}
+	return OpList{{Height: height, Block: b.Hash}}, nil
+}
+
+// events records follower events as "+3a" for blocks and "-3a" for
+// rollbacks.
+type events []string
+
+func (e *events) handle(_ context.Context, ev *FollowEvent) error {
+	sign := "+"
+	if ev.Type == FollowRollback {
+		sign = "-"
+	} else if len(ev.Ops) != 1 || !ev.Ops[0].Block.Equal(ev.Id.Hash) {
+		return fmt.Errorf("block %d without ops", ev.Id.Height)
+	}
+	*e = append(*e, fmt.Sprintf("%s%d%c", sign, ev.Id.Height, ev.Id.Hash[0]))
+	return nil
+}
+
+func (e *events) take() string {
+	s := strings.Join(*e, " ")
+	*e = nil
+	return s
+}
+
+func historyString(f *Follower) string {
+	var s []string
+	for _, id := range f.History() {
+		s = append(s, fmt.Sprintf("%d%c", id.Height, id.Hash[0]))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestFollowerLinear(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(5)
+	f := NewFollower(chain).WithStart(2).WithDepth(3)
+	var ev events
+	if err := f.poll(ctx, ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	if got := ev.take(); got != "+2a +3a +4a +5a" {
+		t.Errorf("events %q", got)
+	}
+	if got := historyString(f); got != "3a 4a 5a" {
+		t.Errorf("history %q, want the last 3 blocks", got)
+	}
+
+	// nothing new
+	if err := f.poll(ctx, ev.handle); err != nil || len(ev) > 0 {
+		t.Errorf("idle poll: %v %v", ev, err)
+	}
+
+	chain.extend(6, 7, 'a')
+	if err := f.poll(ctx, ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	if got := ev.take(); got != "+6a +7a" {
+		t.Errorf("events %q", got)
+	}
+	if f.Checkpoint().Height != 7 {
+		t.Errorf("checkpoint %d", f.Checkpoint().Height)
+	}
+}
+
+func TestFollowerStartAtHead(t *testing.T) {
+	chain := newFakeChain(5)
+	f := NewFollower(chain)
+	var ev events
+	if err := f.poll(context.Background(), ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	if got := ev.take(); got != "+5a" {
+		t.Errorf("events %q", got)
+	}
+}
+
+func TestFollowerReorg(t *testing.T) {
+	tests := []struct {
+		name    string
+		depth   int
+		reorg   func(c *fakeChain)
+		want    string
+		history string
+		wantErr error
+	}{
+		{
+			name:    "same_height_swap",
+			depth:   10,
+			reorg:   func(c *fakeChain) { c.extend(5, 5, 'b') },
+			want:    "-5a +5b",
+			history: "1a 2a 3a 4a 5b",
+		},
+		{
+			name:    "multi_block",
+			depth:   10,
+			reorg:   func(c *fakeChain) { c.extend(3, 6, 'b') },
+			want:    "-5a -4a -3a +3b +4b +5b +6b",
+			history: "1a 2a 3b 4b 5b 6b",
+		},
+		{
+			name:    "shorter_branch",
+			depth:   10,
+			reorg:   func(c *fakeChain) { c.extend(4, 4, 'b') },
+			want:    "-5a -4a +4b",
+			history: "1a 2a 3a 4b",
+		},
+		{
+			name:    "at_depth",
+			depth:   3,
+			reorg:   func(c *fakeChain) { c.extend(3, 5, 'b') },
+			want:    "-5a -4a -3a",
+			wantErr: ErrReorgTooDeep,
+		},
+		{
+			name:    "too_deep",
+			depth:   2,
+			reorg:   func(c *fakeChain) { c.extend(2, 6, 'b') },
+			want:    "-5a -4a",
+			wantErr: ErrReorgTooDeep,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			chain := newFakeChain(5)
+			f := NewFollower(chain).WithStart(1).WithDepth(tt.depth)
+			var ev events
+			if err := f.poll(ctx, ev.handle); err != nil {
+				t.Fatal(err)
+			}
+			ev.take()
+
+			tt.reorg(chain)
+			err := f.poll(ctx, ev.handle)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got := ev.take(); got != tt.want {
+				t.Errorf("events %q, want %q", got, tt.want)
+			}
+			if got := historyString(f); got != tt.history {
+				t.Errorf("history %q, want %q", got, tt.history)
+			}
+		})
+	}
+}
+
+func TestFollowerLaggingHead(t *testing.T) {
+	ctx := context.Background()
+	chain := newFakeChain(5)
+	f := NewFollower(chain).WithStart(1)
+	var ev events
+	if err := f.poll(ctx, ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	ev.take()
+
+	// head lags behind our tip, e.g. after failover to a slower endpoint
+	chain.head = 3
+	if err := f.poll(ctx, ev.handle); err != nil || len(ev) > 0 {
+		t.Fatalf("lagging head: events %v error %v", ev, err)
+	}
+
+	// head went backwards and our tip is gone, wait for the new branch
+	chain.extend(4, 4, 'a')
+	chain.head = 0
+	if err := f.poll(ctx, ev.handle); err != nil || len(ev) > 0 {
+		t.Fatalf("missing tip: events %v error %v", ev, err)
+	}
+	if f.Checkpoint().Height != 5 {
+		t.Errorf("checkpoint %d, want 5", f.Checkpoint().Height)
+	}
+
+	// head went backwards and our tip was replaced
+	chain.extend(4, 5, 'b')
+	chain.head = 4
+	if err := f.poll(ctx, ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	if got := ev.take(); got != "-5a -4a +4b" {
+		t.Errorf("events %q", got)
+	}
+}
+
+func TestFollowerCheckpoint(t *testing.T) {
+	chain := newFakeChain(5)
+	chain.extend(4, 6, 'b')
+
+	// resume after 4a and 5a which were orphaned while we were down
+	f := NewFollower(chain).WithCheckpoint(
+		BlockId{Height: 3, Hash: testHash(3, 'a')},
+		BlockId{Height: 4, Hash: testHash(4, 'a')},
+		BlockId{Height: 5, Hash: testHash(5, 'a')},
+	)
+	var ev events
+	if err := f.poll(context.Background(), ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	if got := ev.take(); got != "-5a -4a +4b +5b +6b" {
+		t.Errorf("events %q", got)
+	}
+}
+
+func TestFollowerHandlerError(t *testing.T) {
+	chain := newFakeChain(5)
+	f := NewFollower(chain).WithStart(1)
+	errStop := errors.New("stop")
+	err := f.poll(context.Background(), func(_ context.Context, e *FollowEvent) error {
+		if e.Id.Height == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("got %v, want handler error", err)
+	}
+	// failed blocks are not checkpointed and retried on resume
+	if f.Checkpoint().Height != 2 {
+		t.Errorf("checkpoint %d, want 2", f.Checkpoint().Height)
+	}
+	var ev events
+	if err := f.poll(context.Background(), ev.handle); err != nil {
+		t.Fatal(err)
+	}
+	if got := ev.take(); got != "+3a +4a +5a" {
+		t.Errorf("events %q", got)
+	}
+}
+
+func TestFollowerCancel(t *testing.T) {
+	chain := newFakeChain(10)
+	f := NewFollower(chain).WithStart(1).WithInterval(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var ev events
+	err := f.Run(ctx, func(ctx context.Context, e *FollowEvent) error {
+		if e.Id.Height == 4 {
+			cancel()
+		}
+		return ev.handle(ctx, e)
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if got := ev.take(); got != "+1a +2a +3a +4a" {
+		t.Errorf("events %q", got)
+	}
+	if f.Checkpoint().Height != 4 {
+		t.Errorf("checkpoint %d, want 4", f.Checkpoint().Height)
+	}
+
+	// cancel inside an API call
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	chain.extend(11, 12, 'a')
+	chain.onCall = func(int) { cancel() }
+	if err := f.Run(ctx, ev.handle); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if f.Checkpoint().Height != 4 || len(ev) > 0 {
+		t.Errorf("follower advanced after cancel: %v", ev)
+	}
+}
@@ -480,7 +480,7 @@ func (c *metaClient) List(ctx context.Context) ([]Metadata, error) {
 
 func (c *metaClient) GetWallet(ctx context.Context, addr Address) (Metadata, error) {
 	var resp Metadata
-	if err := c.client.Get(ctx, "/metadata/"+addr.String(), nil, &resp); err != nil {
+	if err := c.client.GetShared(ctx, "/metadata/"+addr.String(), nil, &resp); err != nil {
 		return resp, err
 	}
 	return resp, nil
@@ -511,7 +511,7 @@ func (c *metaClient) Purge(ctx context.Context) error {
 
 func (c *metaClient) DescribeAny(ctx context.Context, class, ident string) (MetadataDescriptor, error) {
 	var resp MetadataDescriptor
-	if err := c.client.Get(ctx, fmt.Sprintf("/metadata/describe/%s/%s", class, ident), nil, &resp); err != nil {
+	if err := c.client.GetShared(ctx, fmt.Sprintf("/metadata/describe/%s/%s", class, ident), nil, &resp); err != nil {
 		return resp, err
 	}
 	return resp, nil
@@ -519,7 +519,7 @@ func (c *metaClient) DescribeAny(ctx context.Context, class, ident string) (Meta
 
 func (c *metaClient) DescribeAddress(ctx context.Context, addr Address) (MetadataDescriptor, error) {
 	var resp MetadataDescriptor
-	if err := c.client.Get(ctx, fmt.Sprintf("/metadata/describe/%s", addr), nil, &resp); err != nil {
+	if err := c.client.GetShared(ctx, fmt.Sprintf("/metadata/describe/%s", addr), nil, &resp); err != nil {
 		return resp, err
 	}
 	return resp, nil
@@ -527,7 +527,7 @@ func (c *metaClient) DescribeAddress(ctx context.Context, addr Address) (Metadat
 
 func (c *metaClient) GetSchema(ctx context.Context, name string) (json.RawMessage, error) {
 	var msg json.RawMessage
-	if err := c.client.Get(ctx, "/metadata/schemas/"+name, nil, &msg); err != nil {
+	if err := c.client.GetShared(ctx, "/metadata/schemas/"+name, nil, &msg); err != nil {
 		return nil, err
 	}
 	return msg, nil
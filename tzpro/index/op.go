@@ -7,6 +7,7 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 type OpAPI interface {
 	Get(context.Context, OpHash, Query) (OpList, error)
 	ResolveTypes(context.Context, ...*Op) error
+	Decode(context.Context, ...*Op) error
 	NewQuery() *OpQuery
 }
 
@@ -117,12 +119,25 @@ type Op struct {
 	Events        []Event             `json:"events,omitempty"          tzpro:"-"`
 	TicketUpdates []TicketUpdate      `json:"ticket_updates,omitempty"  tzpro:"-"`
 
+	// client-side decoded contract data, see OpAPI.Decode
+	Decoded *DecodeResult `json:"-" tzpro:"-"`
+
 	param   Type           // optional, may be decoded from script
 	store   Type           // optional, may be decoded from script
 	eps     Entrypoints    // optional, may be decoded from script
 	bigmaps map[int64]Type // optional, may be decoded from script
 }
 
+// DecodeResult holds contract data of an operation decoded on the client.
+// Err collects all errors that occurred while decoding, fields that could
+// not be decoded are nil.
+type DecodeResult struct {
+	Params  *ContractParameters
+	Storage *ContractValue
+	Bigmaps BigmapUpdateList
+	Err     error
+}
+
 func (o *Op) BlockId() BlockId {
 	return BlockId{
 		Height: o.Height,
@@ -495,10 +510,77 @@ func (c opClient) ResolveTypes(ctx context.Context, ops ...*Op) error {
 	return nil
 }
 
+// Decode resolves contract types and decodes parameters, storage and
+// bigmap updates of ops and their batch and internal contents. Results and
+// errors are stored in Op.Decoded, so a single broken op does not fail the
+// list. Only context errors are returned. Op table queries decode rows on
+// fetch with WithDecode.
+func (c opClient) Decode(ctx context.Context, ops ...*Op) error {
+	for _, op := range ops {
+		for _, v := range op.Content() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !hasData(v.Parameters) && !hasData(v.Storage) && !hasData(v.BigmapDiff) {
+				continue
+			}
+			v.Decoded = c.decodeOp(ctx, v)
+		}
+	}
+	return nil
+}
+
+// hasData reports whether a raw column is set. Table rows carry unset
+// columns as JSON null.
+func hasData(buf json.RawMessage) bool {
+	return len(buf) > 0 && string(buf) != "null"
+}
+
+func (c opClient) decodeOp(ctx context.Context, o *Op) (res *DecodeResult) {
+	res = &DecodeResult{}
+	var errs []error
+	defer func() {
+		// malformed binary data may panic in the Micheline decoder
+		if e := recover(); e != nil {
+			errs = append(errs, fmt.Errorf("op %s (%d): decoder panic: %v", o.Hash, o.Id, e))
+		}
+		res.Err = errors.Join(errs...)
+	}()
+	if o.IsContract && o.Receiver.IsContract() && !o.param.IsValid() {
+		script, err := c.loadScript(ctx, o.Receiver)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("op %s (%d) loading script %s: %w", o.Hash, o.Id, o.Receiver, err))
+		} else {
+			o.WithScript(script)
+		}
+	}
+	var err error
+	if hasData(o.Parameters) {
+		// DecodeParams only reports errors in noFail mode
+		if res.Params, err = o.DecodeParams(true, 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if hasData(o.Storage) {
+		if res.Storage, err = o.DecodeStorage(false, 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if hasData(o.BigmapDiff) {
+		if res.Bigmaps, err = o.DecodeBigmapUpdates(false, false, 0); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return res
+}
+
 type OpQuery = client.TableQuery[*Op]
 
 func (c opClient) NewQuery() *OpQuery {
-	return client.NewTableQuery[*Op](c.client, "op")
+	q := client.NewTableQuery[*Op](c.client, "op")
+	return q.WithDecoder(func(ctx context.Context, ops []*Op) error {
+		return c.Decode(ctx, ops...)
+	})
 }
 
 func (c opClient) Get(ctx context.Context, hash OpHash, params Query) (OpList, error) {
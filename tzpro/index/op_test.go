@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package index
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestOpHasData(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  json.RawMessage
+		has  bool // Has* methods
+		data bool // decoded on fetch
+	}{
+		{"unset", nil, false, false},
+		{"null", json.RawMessage("null"), true, false},
+		{"hex", json.RawMessage(`"00"`), true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := Op{Parameters: tt.raw, Storage: tt.raw, BigmapDiff: tt.raw}
+			if o.HasParameters() != tt.has || o.HasStorage() != tt.has || o.HasBigmapUpdates() != tt.has {
+				t.Errorf("Has* = %t %t %t, want %t", o.HasParameters(), o.HasStorage(), o.HasBigmapUpdates(), tt.has)
+			}
+			if hasData(tt.raw) != tt.data {
+				t.Errorf("hasData = %t, want %t", hasData(tt.raw), tt.data)
+			}
+		})
+	}
+}
+
+func TestOpDecodeParamsNoFail(t *testing.T) {
+	// existing callers rely on errors being reported only with noFail set
+	o := Op{Type: OpTypeTransaction, Parameters: json.RawMessage(`"zz"`)}
+	var herr hex.InvalidByteError
+	if _, err := o.DecodeParams(true, 0); !errors.As(err, &herr) {
+		t.Errorf("noFail mode returned %v, want hex error", err)
+	}
+	if _, err := o.DecodeParams(false, 0); errors.As(err, &herr) {
+		t.Errorf("default mode returned hex error %v", err)
+	}
+}
+
+func TestDecodeOps(t *testing.T) {
+	var (
+		c      = opClient{}
+		broken = &Op{Type: OpTypeTransaction, Parameters: json.RawMessage(`"zz"`)}
+		empty  = &Op{Type: OpTypeTransaction, Parameters: json.RawMessage("null"), Storage: json.RawMessage("null")}
+		batch  = &Op{Type: OpTypeBatch, Batch: []*Op{empty, broken}}
+	)
+	if err := c.Decode(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if empty.Decoded != nil {
+		t.Errorf("decoded op without contract data: %+v", empty.Decoded)
+	}
+	// decoding on fetch is strict and keeps the underlying error
+	var herr hex.InvalidByteError
+	if broken.Decoded == nil || !errors.As(broken.Decoded.Err, &herr) || broken.Decoded.Params != nil {
+		t.Errorf("broken op: got %+v, want per-op hex error", broken.Decoded)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Decode(ctx, broken); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
@@ -29,8 +29,8 @@ func (s *Status) UnmarshalJSON(data []byte) error {
 	if data[0] == '[' {
 		return client.Decode(data, nil, s)
 	}
-	type alias *Status
-	return json.Unmarshal(data, alias(s))
+	type alias Status
+	return json.Unmarshal(data, (*alias)(s))
 }
 
 func (c *explorerClient) GetStatus(ctx context.Context) (*Status, error) {
@@ -38,5 +38,6 @@ func (c *explorerClient) GetStatus(ctx context.Context) (*Status, error) {
 	if err := c.client.Get(ctx, "/explorer/status", nil, s); err != nil {
 		return nil, err
 	}
+	c.client.SetFinalized(s.Finalized)
 	return s, nil
 }
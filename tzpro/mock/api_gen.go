@@ -0,0 +1,1580 @@
+// Code generated by mockgen. DO NOT EDIT.
+
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"blockwatch.cc/tzpro-go/tzpro/defi"
+	"blockwatch.cc/tzpro-go/tzpro/identity"
+	"blockwatch.cc/tzpro-go/tzpro/index"
+	"blockwatch.cc/tzpro-go/tzpro/ipfs"
+	"blockwatch.cc/tzpro-go/tzpro/market"
+	"blockwatch.cc/tzpro-go/tzpro/nft"
+	"blockwatch.cc/tzpro-go/tzpro/token"
+	"blockwatch.cc/tzpro-go/tzpro/wallet"
+	"blockwatch.cc/tzpro-go/tzpro/zmq"
+)
+
+var (
+	_ defi.DexAPI         = (*DexAPI)(nil)
+	_ defi.FarmAPI        = (*FarmAPI)(nil)
+	_ defi.LendingAPI     = (*LendingAPI)(nil)
+	_ identity.DomainAPI  = (*DomainAPI)(nil)
+	_ identity.ProfileAPI = (*ProfileAPI)(nil)
+	_ index.AccountAPI    = (*AccountAPI)(nil)
+	_ index.BakerAPI      = (*BakerAPI)(nil)
+	_ index.BlockAPI      = (*BlockAPI)(nil)
+	_ index.ContractAPI   = (*ContractAPI)(nil)
+	_ index.ExplorerAPI   = (*ExplorerAPI)(nil)
+	_ index.MetadataAPI   = (*MetadataAPI)(nil)
+	_ index.OpAPI         = (*OpAPI)(nil)
+	_ index.StatsAPI      = (*StatsAPI)(nil)
+	_ ipfs.IpfsAPI        = (*IpfsAPI)(nil)
+	_ market.MarketAPI    = (*MarketAPI)(nil)
+	_ nft.NftAPI          = (*NftAPI)(nil)
+	_ token.TokenAPI      = (*TokenAPI)(nil)
+	_ wallet.WalletAPI    = (*WalletAPI)(nil)
+	_ zmq.ZmqAPI          = (*ZmqAPI)(nil)
+)
+
+// DexAPI is a configurable fake of defi.DexAPI.
+type DexAPI struct {
+	Recorder
+	GetDexFunc            func(context.Context, defi.PoolAddress) (*defi.Dex, error)
+	GetTickerFunc         func(context.Context, defi.PoolAddress) (*defi.DexTicker, error)
+	ListPoolEventsFunc    func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.DexEvent, error)
+	ListPoolTradesFunc    func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.DexTrade, error)
+	ListPoolPositionsFunc func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.DexPosition, error)
+	ListDexFunc           func(context.Context, defi.Query) ([]*defi.Dex, error)
+	ListTickersFunc       func(context.Context, defi.Query) ([]*defi.DexTicker, error)
+	ListEventsFunc        func(context.Context, defi.Query) ([]*defi.DexEvent, error)
+	ListTradesFunc        func(context.Context, defi.Query) ([]*defi.DexTrade, error)
+	ListPositionsFunc     func(context.Context, defi.Query) ([]*defi.DexPosition, error)
+}
+
+func (m *DexAPI) GetDex(p0 context.Context, p1 defi.PoolAddress) (*defi.Dex, error) {
+	m.record("GetDex", p0, p1)
+	if m.GetDexFunc != nil {
+		return m.GetDexFunc(p0, p1)
+	}
+	var r0 *defi.Dex
+	return r0, notConfigured("DexAPI.GetDex")
+}
+
+func (m *DexAPI) GetTicker(p0 context.Context, p1 defi.PoolAddress) (*defi.DexTicker, error) {
+	m.record("GetTicker", p0, p1)
+	if m.GetTickerFunc != nil {
+		return m.GetTickerFunc(p0, p1)
+	}
+	var r0 *defi.DexTicker
+	return r0, notConfigured("DexAPI.GetTicker")
+}
+
+func (m *DexAPI) ListPoolEvents(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.DexEvent, error) {
+	m.record("ListPoolEvents", p0, p1, p2)
+	if m.ListPoolEventsFunc != nil {
+		return m.ListPoolEventsFunc(p0, p1, p2)
+	}
+	var r0 []*defi.DexEvent
+	return r0, notConfigured("DexAPI.ListPoolEvents")
+}
+
+func (m *DexAPI) ListPoolTrades(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.DexTrade, error) {
+	m.record("ListPoolTrades", p0, p1, p2)
+	if m.ListPoolTradesFunc != nil {
+		return m.ListPoolTradesFunc(p0, p1, p2)
+	}
+	var r0 []*defi.DexTrade
+	return r0, notConfigured("DexAPI.ListPoolTrades")
+}
+
+func (m *DexAPI) ListPoolPositions(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.DexPosition, error) {
+	m.record("ListPoolPositions", p0, p1, p2)
+	if m.ListPoolPositionsFunc != nil {
+		return m.ListPoolPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*defi.DexPosition
+	return r0, notConfigured("DexAPI.ListPoolPositions")
+}
+
+func (m *DexAPI) ListDex(p0 context.Context, p1 defi.Query) ([]*defi.Dex, error) {
+	m.record("ListDex", p0, p1)
+	if m.ListDexFunc != nil {
+		return m.ListDexFunc(p0, p1)
+	}
+	var r0 []*defi.Dex
+	return r0, notConfigured("DexAPI.ListDex")
+}
+
+func (m *DexAPI) ListTickers(p0 context.Context, p1 defi.Query) ([]*defi.DexTicker, error) {
+	m.record("ListTickers", p0, p1)
+	if m.ListTickersFunc != nil {
+		return m.ListTickersFunc(p0, p1)
+	}
+	var r0 []*defi.DexTicker
+	return r0, notConfigured("DexAPI.ListTickers")
+}
+
+func (m *DexAPI) ListEvents(p0 context.Context, p1 defi.Query) ([]*defi.DexEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*defi.DexEvent
+	return r0, notConfigured("DexAPI.ListEvents")
+}
+
+func (m *DexAPI) ListTrades(p0 context.Context, p1 defi.Query) ([]*defi.DexTrade, error) {
+	m.record("ListTrades", p0, p1)
+	if m.ListTradesFunc != nil {
+		return m.ListTradesFunc(p0, p1)
+	}
+	var r0 []*defi.DexTrade
+	return r0, notConfigured("DexAPI.ListTrades")
+}
+
+func (m *DexAPI) ListPositions(p0 context.Context, p1 defi.Query) ([]*defi.DexPosition, error) {
+	m.record("ListPositions", p0, p1)
+	if m.ListPositionsFunc != nil {
+		return m.ListPositionsFunc(p0, p1)
+	}
+	var r0 []*defi.DexPosition
+	return r0, notConfigured("DexAPI.ListPositions")
+}
+
+// FarmAPI is a configurable fake of defi.FarmAPI.
+type FarmAPI struct {
+	Recorder
+	GetFarmFunc               func(context.Context, defi.PoolAddress) (*defi.Farm, error)
+	ListPoolEventsFunc        func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.FarmEvent, error)
+	ListFarmPoolPositionsFunc func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.FarmPosition, error)
+	ListFarmsFunc             func(context.Context, defi.Query) ([]*defi.Farm, error)
+	ListEventsFunc            func(context.Context, defi.Query) ([]*defi.FarmEvent, error)
+	ListPositionsFunc         func(context.Context, defi.Query) ([]*defi.FarmPosition, error)
+}
+
+func (m *FarmAPI) GetFarm(p0 context.Context, p1 defi.PoolAddress) (*defi.Farm, error) {
+	m.record("GetFarm", p0, p1)
+	if m.GetFarmFunc != nil {
+		return m.GetFarmFunc(p0, p1)
+	}
+	var r0 *defi.Farm
+	return r0, notConfigured("FarmAPI.GetFarm")
+}
+
+func (m *FarmAPI) ListPoolEvents(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.FarmEvent, error) {
+	m.record("ListPoolEvents", p0, p1, p2)
+	if m.ListPoolEventsFunc != nil {
+		return m.ListPoolEventsFunc(p0, p1, p2)
+	}
+	var r0 []*defi.FarmEvent
+	return r0, notConfigured("FarmAPI.ListPoolEvents")
+}
+
+func (m *FarmAPI) ListFarmPoolPositions(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.FarmPosition, error) {
+	m.record("ListFarmPoolPositions", p0, p1, p2)
+	if m.ListFarmPoolPositionsFunc != nil {
+		return m.ListFarmPoolPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*defi.FarmPosition
+	return r0, notConfigured("FarmAPI.ListFarmPoolPositions")
+}
+
+func (m *FarmAPI) ListFarms(p0 context.Context, p1 defi.Query) ([]*defi.Farm, error) {
+	m.record("ListFarms", p0, p1)
+	if m.ListFarmsFunc != nil {
+		return m.ListFarmsFunc(p0, p1)
+	}
+	var r0 []*defi.Farm
+	return r0, notConfigured("FarmAPI.ListFarms")
+}
+
+func (m *FarmAPI) ListEvents(p0 context.Context, p1 defi.Query) ([]*defi.FarmEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*defi.FarmEvent
+	return r0, notConfigured("FarmAPI.ListEvents")
+}
+
+func (m *FarmAPI) ListPositions(p0 context.Context, p1 defi.Query) ([]*defi.FarmPosition, error) {
+	m.record("ListPositions", p0, p1)
+	if m.ListPositionsFunc != nil {
+		return m.ListPositionsFunc(p0, p1)
+	}
+	var r0 []*defi.FarmPosition
+	return r0, notConfigured("FarmAPI.ListPositions")
+}
+
+// LendingAPI is a configurable fake of defi.LendingAPI.
+type LendingAPI struct {
+	Recorder
+	GetPoolFunc           func(context.Context, defi.PoolAddress) (*defi.LendingPool, error)
+	ListPoolEventsFunc    func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.LendingEvent, error)
+	ListPoolPositionsFunc func(context.Context, defi.PoolAddress, defi.Query) ([]*defi.LendingPosition, error)
+	ListPoolsFunc         func(context.Context, defi.Query) ([]*defi.LendingPool, error)
+	ListEventsFunc        func(context.Context, defi.Query) ([]*defi.LendingEvent, error)
+	ListPositionsFunc     func(context.Context, defi.Query) ([]*defi.LendingPosition, error)
+}
+
+func (m *LendingAPI) GetPool(p0 context.Context, p1 defi.PoolAddress) (*defi.LendingPool, error) {
+	m.record("GetPool", p0, p1)
+	if m.GetPoolFunc != nil {
+		return m.GetPoolFunc(p0, p1)
+	}
+	var r0 *defi.LendingPool
+	return r0, notConfigured("LendingAPI.GetPool")
+}
+
+func (m *LendingAPI) ListPoolEvents(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.LendingEvent, error) {
+	m.record("ListPoolEvents", p0, p1, p2)
+	if m.ListPoolEventsFunc != nil {
+		return m.ListPoolEventsFunc(p0, p1, p2)
+	}
+	var r0 []*defi.LendingEvent
+	return r0, notConfigured("LendingAPI.ListPoolEvents")
+}
+
+func (m *LendingAPI) ListPoolPositions(p0 context.Context, p1 defi.PoolAddress, p2 defi.Query) ([]*defi.LendingPosition, error) {
+	m.record("ListPoolPositions", p0, p1, p2)
+	if m.ListPoolPositionsFunc != nil {
+		return m.ListPoolPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*defi.LendingPosition
+	return r0, notConfigured("LendingAPI.ListPoolPositions")
+}
+
+func (m *LendingAPI) ListPools(p0 context.Context, p1 defi.Query) ([]*defi.LendingPool, error) {
+	m.record("ListPools", p0, p1)
+	if m.ListPoolsFunc != nil {
+		return m.ListPoolsFunc(p0, p1)
+	}
+	var r0 []*defi.LendingPool
+	return r0, notConfigured("LendingAPI.ListPools")
+}
+
+func (m *LendingAPI) ListEvents(p0 context.Context, p1 defi.Query) ([]*defi.LendingEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*defi.LendingEvent
+	return r0, notConfigured("LendingAPI.ListEvents")
+}
+
+func (m *LendingAPI) ListPositions(p0 context.Context, p1 defi.Query) ([]*defi.LendingPosition, error) {
+	m.record("ListPositions", p0, p1)
+	if m.ListPositionsFunc != nil {
+		return m.ListPositionsFunc(p0, p1)
+	}
+	var r0 []*defi.LendingPosition
+	return r0, notConfigured("LendingAPI.ListPositions")
+}
+
+// DomainAPI is a configurable fake of identity.DomainAPI.
+type DomainAPI struct {
+	Recorder
+	LookupByNameFunc    func(context.Context, string) (*identity.Domain, error)
+	LookupByAddressFunc func(context.Context, identity.Address) (*identity.Domain, error)
+	ListDomainsFunc     func(context.Context, identity.Query) ([]*identity.Domain, error)
+	ListEventsFunc      func(context.Context, identity.Query) ([]*identity.DomainEvent, error)
+}
+
+func (m *DomainAPI) LookupByName(p0 context.Context, p1 string) (*identity.Domain, error) {
+	m.record("LookupByName", p0, p1)
+	if m.LookupByNameFunc != nil {
+		return m.LookupByNameFunc(p0, p1)
+	}
+	var r0 *identity.Domain
+	return r0, notConfigured("DomainAPI.LookupByName")
+}
+
+func (m *DomainAPI) LookupByAddress(p0 context.Context, p1 identity.Address) (*identity.Domain, error) {
+	m.record("LookupByAddress", p0, p1)
+	if m.LookupByAddressFunc != nil {
+		return m.LookupByAddressFunc(p0, p1)
+	}
+	var r0 *identity.Domain
+	return r0, notConfigured("DomainAPI.LookupByAddress")
+}
+
+func (m *DomainAPI) ListDomains(p0 context.Context, p1 identity.Query) ([]*identity.Domain, error) {
+	m.record("ListDomains", p0, p1)
+	if m.ListDomainsFunc != nil {
+		return m.ListDomainsFunc(p0, p1)
+	}
+	var r0 []*identity.Domain
+	return r0, notConfigured("DomainAPI.ListDomains")
+}
+
+func (m *DomainAPI) ListEvents(p0 context.Context, p1 identity.Query) ([]*identity.DomainEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*identity.DomainEvent
+	return r0, notConfigured("DomainAPI.ListEvents")
+}
+
+// ProfileAPI is a configurable fake of identity.ProfileAPI.
+type ProfileAPI struct {
+	Recorder
+	ListProfilesFunc func(context.Context, identity.Query) ([]*identity.Profile, error)
+	ListEventsFunc   func(context.Context, identity.Query) ([]*identity.ProfileEvent, error)
+	ListClaimsFunc   func(context.Context, identity.Query) ([]*identity.ProfileClaim, error)
+}
+
+func (m *ProfileAPI) ListProfiles(p0 context.Context, p1 identity.Query) ([]*identity.Profile, error) {
+	m.record("ListProfiles", p0, p1)
+	if m.ListProfilesFunc != nil {
+		return m.ListProfilesFunc(p0, p1)
+	}
+	var r0 []*identity.Profile
+	return r0, notConfigured("ProfileAPI.ListProfiles")
+}
+
+func (m *ProfileAPI) ListEvents(p0 context.Context, p1 identity.Query) ([]*identity.ProfileEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*identity.ProfileEvent
+	return r0, notConfigured("ProfileAPI.ListEvents")
+}
+
+func (m *ProfileAPI) ListClaims(p0 context.Context, p1 identity.Query) ([]*identity.ProfileClaim, error) {
+	m.record("ListClaims", p0, p1)
+	if m.ListClaimsFunc != nil {
+		return m.ListClaimsFunc(p0, p1)
+	}
+	var r0 []*identity.ProfileClaim
+	return r0, notConfigured("ProfileAPI.ListClaims")
+}
+
+// AccountAPI is a configurable fake of index.AccountAPI.
+type AccountAPI struct {
+	Recorder
+	GetFunc                func(context.Context, index.Address, index.Query) (*index.Account, error)
+	ListOpsFunc            func(context.Context, index.Address, index.Query) (index.OpList, error)
+	ListContractsFunc      func(context.Context, index.Address, index.Query) (index.ContractList, error)
+	ListTicketBalancesFunc func(context.Context, index.Address, index.Query) (index.TicketBalanceList, error)
+	ListTicketEventsFunc   func(context.Context, index.Address, index.Query) (index.TicketEventList, error)
+	NewQueryFunc           func() *index.AccountQuery
+	NewFlowQueryFunc       func() *index.FlowQuery
+}
+
+func (m *AccountAPI) Get(p0 context.Context, p1 index.Address, p2 index.Query) (*index.Account, error) {
+	m.record("Get", p0, p1, p2)
+	if m.GetFunc != nil {
+		return m.GetFunc(p0, p1, p2)
+	}
+	var r0 *index.Account
+	return r0, notConfigured("AccountAPI.Get")
+}
+
+func (m *AccountAPI) ListOps(p0 context.Context, p1 index.Address, p2 index.Query) (index.OpList, error) {
+	m.record("ListOps", p0, p1, p2)
+	if m.ListOpsFunc != nil {
+		return m.ListOpsFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("AccountAPI.ListOps")
+}
+
+func (m *AccountAPI) ListContracts(p0 context.Context, p1 index.Address, p2 index.Query) (index.ContractList, error) {
+	m.record("ListContracts", p0, p1, p2)
+	if m.ListContractsFunc != nil {
+		return m.ListContractsFunc(p0, p1, p2)
+	}
+	var r0 index.ContractList
+	return r0, notConfigured("AccountAPI.ListContracts")
+}
+
+func (m *AccountAPI) ListTicketBalances(p0 context.Context, p1 index.Address, p2 index.Query) (index.TicketBalanceList, error) {
+	m.record("ListTicketBalances", p0, p1, p2)
+	if m.ListTicketBalancesFunc != nil {
+		return m.ListTicketBalancesFunc(p0, p1, p2)
+	}
+	var r0 index.TicketBalanceList
+	return r0, notConfigured("AccountAPI.ListTicketBalances")
+}
+
+func (m *AccountAPI) ListTicketEvents(p0 context.Context, p1 index.Address, p2 index.Query) (index.TicketEventList, error) {
+	m.record("ListTicketEvents", p0, p1, p2)
+	if m.ListTicketEventsFunc != nil {
+		return m.ListTicketEventsFunc(p0, p1, p2)
+	}
+	var r0 index.TicketEventList
+	return r0, notConfigured("AccountAPI.ListTicketEvents")
+}
+
+func (m *AccountAPI) NewQuery() *index.AccountQuery {
+	m.record("NewQuery")
+	if m.NewQueryFunc != nil {
+		return m.NewQueryFunc()
+	}
+	var r0 *index.AccountQuery
+	return r0
+}
+
+func (m *AccountAPI) NewFlowQuery() *index.FlowQuery {
+	m.record("NewFlowQuery")
+	if m.NewFlowQueryFunc != nil {
+		return m.NewFlowQueryFunc()
+	}
+	var r0 *index.FlowQuery
+	return r0
+}
+
+// BakerAPI is a configurable fake of index.BakerAPI.
+type BakerAPI struct {
+	Recorder
+	GetFunc                   func(context.Context, index.Address, index.Query) (*index.Baker, error)
+	ListFunc                  func(context.Context, index.Query) (index.BakerList, error)
+	ListVotesFunc             func(context.Context, index.Address, index.Query) (index.BallotList, error)
+	ListEndorsementsFunc      func(context.Context, index.Address, index.Query) (index.OpList, error)
+	ListDelegationsFunc       func(context.Context, index.Address, index.Query) (index.OpList, error)
+	GetRightsFunc             func(context.Context, index.Address, int64, index.Query) (*index.Rights, error)
+	GetIncomeFunc             func(context.Context, index.Address, int64, index.Query) (*index.Income, error)
+	GetSnapshotFunc           func(context.Context, index.Address, int64, index.Query) (*index.Snapshot, error)
+	NewIncomeQueryFunc        func() *index.IncomeQuery
+	NewRightsQueryFunc        func() *index.RightsQuery
+	NewStakeSnapshotQueryFunc func() *index.StakeSnapshotQuery
+}
+
+func (m *BakerAPI) Get(p0 context.Context, p1 index.Address, p2 index.Query) (*index.Baker, error) {
+	m.record("Get", p0, p1, p2)
+	if m.GetFunc != nil {
+		return m.GetFunc(p0, p1, p2)
+	}
+	var r0 *index.Baker
+	return r0, notConfigured("BakerAPI.Get")
+}
+
+func (m *BakerAPI) List(p0 context.Context, p1 index.Query) (index.BakerList, error) {
+	m.record("List", p0, p1)
+	if m.ListFunc != nil {
+		return m.ListFunc(p0, p1)
+	}
+	var r0 index.BakerList
+	return r0, notConfigured("BakerAPI.List")
+}
+
+func (m *BakerAPI) ListVotes(p0 context.Context, p1 index.Address, p2 index.Query) (index.BallotList, error) {
+	m.record("ListVotes", p0, p1, p2)
+	if m.ListVotesFunc != nil {
+		return m.ListVotesFunc(p0, p1, p2)
+	}
+	var r0 index.BallotList
+	return r0, notConfigured("BakerAPI.ListVotes")
+}
+
+func (m *BakerAPI) ListEndorsements(p0 context.Context, p1 index.Address, p2 index.Query) (index.OpList, error) {
+	m.record("ListEndorsements", p0, p1, p2)
+	if m.ListEndorsementsFunc != nil {
+		return m.ListEndorsementsFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("BakerAPI.ListEndorsements")
+}
+
+func (m *BakerAPI) ListDelegations(p0 context.Context, p1 index.Address, p2 index.Query) (index.OpList, error) {
+	m.record("ListDelegations", p0, p1, p2)
+	if m.ListDelegationsFunc != nil {
+		return m.ListDelegationsFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("BakerAPI.ListDelegations")
+}
+
+func (m *BakerAPI) GetRights(p0 context.Context, p1 index.Address, p2 int64, p3 index.Query) (*index.Rights, error) {
+	m.record("GetRights", p0, p1, p2, p3)
+	if m.GetRightsFunc != nil {
+		return m.GetRightsFunc(p0, p1, p2, p3)
+	}
+	var r0 *index.Rights
+	return r0, notConfigured("BakerAPI.GetRights")
+}
+
+func (m *BakerAPI) GetIncome(p0 context.Context, p1 index.Address, p2 int64, p3 index.Query) (*index.Income, error) {
+	m.record("GetIncome", p0, p1, p2, p3)
+	if m.GetIncomeFunc != nil {
+		return m.GetIncomeFunc(p0, p1, p2, p3)
+	}
+	var r0 *index.Income
+	return r0, notConfigured("BakerAPI.GetIncome")
+}
+
+func (m *BakerAPI) GetSnapshot(p0 context.Context, p1 index.Address, p2 int64, p3 index.Query) (*index.Snapshot, error) {
+	m.record("GetSnapshot", p0, p1, p2, p3)
+	if m.GetSnapshotFunc != nil {
+		return m.GetSnapshotFunc(p0, p1, p2, p3)
+	}
+	var r0 *index.Snapshot
+	return r0, notConfigured("BakerAPI.GetSnapshot")
+}
+
+func (m *BakerAPI) NewIncomeQuery() *index.IncomeQuery {
+	m.record("NewIncomeQuery")
+	if m.NewIncomeQueryFunc != nil {
+		return m.NewIncomeQueryFunc()
+	}
+	var r0 *index.IncomeQuery
+	return r0
+}
+
+func (m *BakerAPI) NewRightsQuery() *index.RightsQuery {
+	m.record("NewRightsQuery")
+	if m.NewRightsQueryFunc != nil {
+		return m.NewRightsQueryFunc()
+	}
+	var r0 *index.RightsQuery
+	return r0
+}
+
+func (m *BakerAPI) NewStakeSnapshotQuery() *index.StakeSnapshotQuery {
+	m.record("NewStakeSnapshotQuery")
+	if m.NewStakeSnapshotQueryFunc != nil {
+		return m.NewStakeSnapshotQueryFunc()
+	}
+	var r0 *index.StakeSnapshotQuery
+	return r0
+}
+
+// BlockAPI is a configurable fake of index.BlockAPI.
+type BlockAPI struct {
+	Recorder
+	GetHashFunc       func(context.Context, index.BlockHash, index.Query) (*index.Block, error)
+	GetHeadFunc       func(context.Context, index.Query) (*index.Block, error)
+	GetHeightFunc     func(context.Context, int64, index.Query) (*index.Block, error)
+	ListOpsHashFunc   func(context.Context, index.BlockHash, index.Query) (index.OpList, error)
+	ListOpsHeightFunc func(context.Context, int64, index.Query) (index.OpList, error)
+	NewQueryFunc      func() *index.BlockQuery
+}
+
+func (m *BlockAPI) GetHash(p0 context.Context, p1 index.BlockHash, p2 index.Query) (*index.Block, error) {
+	m.record("GetHash", p0, p1, p2)
+	if m.GetHashFunc != nil {
+		return m.GetHashFunc(p0, p1, p2)
+	}
+	var r0 *index.Block
+	return r0, notConfigured("BlockAPI.GetHash")
+}
+
+func (m *BlockAPI) GetHead(p0 context.Context, p1 index.Query) (*index.Block, error) {
+	m.record("GetHead", p0, p1)
+	if m.GetHeadFunc != nil {
+		return m.GetHeadFunc(p0, p1)
+	}
+	var r0 *index.Block
+	return r0, notConfigured("BlockAPI.GetHead")
+}
+
+func (m *BlockAPI) GetHeight(p0 context.Context, p1 int64, p2 index.Query) (*index.Block, error) {
+	m.record("GetHeight", p0, p1, p2)
+	if m.GetHeightFunc != nil {
+		return m.GetHeightFunc(p0, p1, p2)
+	}
+	var r0 *index.Block
+	return r0, notConfigured("BlockAPI.GetHeight")
+}
+
+func (m *BlockAPI) ListOpsHash(p0 context.Context, p1 index.BlockHash, p2 index.Query) (index.OpList, error) {
+	m.record("ListOpsHash", p0, p1, p2)
+	if m.ListOpsHashFunc != nil {
+		return m.ListOpsHashFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("BlockAPI.ListOpsHash")
+}
+
+func (m *BlockAPI) ListOpsHeight(p0 context.Context, p1 int64, p2 index.Query) (index.OpList, error) {
+	m.record("ListOpsHeight", p0, p1, p2)
+	if m.ListOpsHeightFunc != nil {
+		return m.ListOpsHeightFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("BlockAPI.ListOpsHeight")
+}
+
+func (m *BlockAPI) NewQuery() *index.BlockQuery {
+	m.record("NewQuery")
+	if m.NewQueryFunc != nil {
+		return m.NewQueryFunc()
+	}
+	var r0 *index.BlockQuery
+	return r0
+}
+
+// ContractAPI is a configurable fake of index.ContractAPI.
+type ContractAPI struct {
+	Recorder
+	GetFunc                  func(context.Context, index.Address, index.Query) (*index.Contract, error)
+	GetScriptFunc            func(context.Context, index.Address, index.Query) (*index.ContractScript, error)
+	GetStorageFunc           func(context.Context, index.Address, index.Query) (*index.ContractValue, error)
+	ListCallsFunc            func(context.Context, index.Address, index.Query) (index.OpList, error)
+	GetConstantFunc          func(context.Context, index.ExprHash, index.Query) (*index.Constant, error)
+	GetBigmapFunc            func(context.Context, int64, index.Query) (*index.Bigmap, error)
+	GetBigmapValueFunc       func(context.Context, int64, string, index.Query) (*index.BigmapValue, error)
+	ListBigmapValuesFunc     func(context.Context, int64, index.Query) (index.BigmapValueList, error)
+	ListBigmapKeyUpdatesFunc func(context.Context, int64, string, index.Query) (index.BigmapUpdateList, error)
+	ListBigmapUpdatesFunc    func(context.Context, int64, index.Query) (index.BigmapUpdateList, error)
+	ListTicketsFunc          func(context.Context, index.Address, index.Query) (index.TicketList, error)
+	ListTicketBalancesFunc   func(context.Context, index.Address, index.Query) (index.TicketBalanceList, error)
+	ListTicketEventsFunc     func(context.Context, index.Address, index.Query) (index.TicketEventList, error)
+	NewQueryFunc             func() *index.ContractQuery
+	NewEventQueryFunc        func() *index.EventQuery
+	NewConstantQueryFunc     func() *index.ConstantQuery
+	NewBigmapQueryFunc       func() *index.BigmapQuery
+	NewBigmapValueQueryFunc  func() *index.BigmapValueQuery
+	NewBigmapUpdateQueryFunc func() *index.BigmapUpdateQuery
+}
+
+func (m *ContractAPI) Get(p0 context.Context, p1 index.Address, p2 index.Query) (*index.Contract, error) {
+	m.record("Get", p0, p1, p2)
+	if m.GetFunc != nil {
+		return m.GetFunc(p0, p1, p2)
+	}
+	var r0 *index.Contract
+	return r0, notConfigured("ContractAPI.Get")
+}
+
+func (m *ContractAPI) GetScript(p0 context.Context, p1 index.Address, p2 index.Query) (*index.ContractScript, error) {
+	m.record("GetScript", p0, p1, p2)
+	if m.GetScriptFunc != nil {
+		return m.GetScriptFunc(p0, p1, p2)
+	}
+	var r0 *index.ContractScript
+	return r0, notConfigured("ContractAPI.GetScript")
+}
+
+func (m *ContractAPI) GetStorage(p0 context.Context, p1 index.Address, p2 index.Query) (*index.ContractValue, error) {
+	m.record("GetStorage", p0, p1, p2)
+	if m.GetStorageFunc != nil {
+		return m.GetStorageFunc(p0, p1, p2)
+	}
+	var r0 *index.ContractValue
+	return r0, notConfigured("ContractAPI.GetStorage")
+}
+
+func (m *ContractAPI) ListCalls(p0 context.Context, p1 index.Address, p2 index.Query) (index.OpList, error) {
+	m.record("ListCalls", p0, p1, p2)
+	if m.ListCallsFunc != nil {
+		return m.ListCallsFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("ContractAPI.ListCalls")
+}
+
+func (m *ContractAPI) GetConstant(p0 context.Context, p1 index.ExprHash, p2 index.Query) (*index.Constant, error) {
+	m.record("GetConstant", p0, p1, p2)
+	if m.GetConstantFunc != nil {
+		return m.GetConstantFunc(p0, p1, p2)
+	}
+	var r0 *index.Constant
+	return r0, notConfigured("ContractAPI.GetConstant")
+}
+
+func (m *ContractAPI) GetBigmap(p0 context.Context, p1 int64, p2 index.Query) (*index.Bigmap, error) {
+	m.record("GetBigmap", p0, p1, p2)
+	if m.GetBigmapFunc != nil {
+		return m.GetBigmapFunc(p0, p1, p2)
+	}
+	var r0 *index.Bigmap
+	return r0, notConfigured("ContractAPI.GetBigmap")
+}
+
+func (m *ContractAPI) GetBigmapValue(p0 context.Context, p1 int64, p2 string, p3 index.Query) (*index.BigmapValue, error) {
+	m.record("GetBigmapValue", p0, p1, p2, p3)
+	if m.GetBigmapValueFunc != nil {
+		return m.GetBigmapValueFunc(p0, p1, p2, p3)
+	}
+	var r0 *index.BigmapValue
+	return r0, notConfigured("ContractAPI.GetBigmapValue")
+}
+
+func (m *ContractAPI) ListBigmapValues(p0 context.Context, p1 int64, p2 index.Query) (index.BigmapValueList, error) {
+	m.record("ListBigmapValues", p0, p1, p2)
+	if m.ListBigmapValuesFunc != nil {
+		return m.ListBigmapValuesFunc(p0, p1, p2)
+	}
+	var r0 index.BigmapValueList
+	return r0, notConfigured("ContractAPI.ListBigmapValues")
+}
+
+func (m *ContractAPI) ListBigmapKeyUpdates(p0 context.Context, p1 int64, p2 string, p3 index.Query) (index.BigmapUpdateList, error) {
+	m.record("ListBigmapKeyUpdates", p0, p1, p2, p3)
+	if m.ListBigmapKeyUpdatesFunc != nil {
+		return m.ListBigmapKeyUpdatesFunc(p0, p1, p2, p3)
+	}
+	var r0 index.BigmapUpdateList
+	return r0, notConfigured("ContractAPI.ListBigmapKeyUpdates")
+}
+
+func (m *ContractAPI) ListBigmapUpdates(p0 context.Context, p1 int64, p2 index.Query) (index.BigmapUpdateList, error) {
+	m.record("ListBigmapUpdates", p0, p1, p2)
+	if m.ListBigmapUpdatesFunc != nil {
+		return m.ListBigmapUpdatesFunc(p0, p1, p2)
+	}
+	var r0 index.BigmapUpdateList
+	return r0, notConfigured("ContractAPI.ListBigmapUpdates")
+}
+
+func (m *ContractAPI) ListTickets(p0 context.Context, p1 index.Address, p2 index.Query) (index.TicketList, error) {
+	m.record("ListTickets", p0, p1, p2)
+	if m.ListTicketsFunc != nil {
+		return m.ListTicketsFunc(p0, p1, p2)
+	}
+	var r0 index.TicketList
+	return r0, notConfigured("ContractAPI.ListTickets")
+}
+
+func (m *ContractAPI) ListTicketBalances(p0 context.Context, p1 index.Address, p2 index.Query) (index.TicketBalanceList, error) {
+	m.record("ListTicketBalances", p0, p1, p2)
+	if m.ListTicketBalancesFunc != nil {
+		return m.ListTicketBalancesFunc(p0, p1, p2)
+	}
+	var r0 index.TicketBalanceList
+	return r0, notConfigured("ContractAPI.ListTicketBalances")
+}
+
+func (m *ContractAPI) ListTicketEvents(p0 context.Context, p1 index.Address, p2 index.Query) (index.TicketEventList, error) {
+	m.record("ListTicketEvents", p0, p1, p2)
+	if m.ListTicketEventsFunc != nil {
+		return m.ListTicketEventsFunc(p0, p1, p2)
+	}
+	var r0 index.TicketEventList
+	return r0, notConfigured("ContractAPI.ListTicketEvents")
+}
+
+func (m *ContractAPI) NewQuery() *index.ContractQuery {
+	m.record("NewQuery")
+	if m.NewQueryFunc != nil {
+		return m.NewQueryFunc()
+	}
+	var r0 *index.ContractQuery
+	return r0
+}
+
+func (m *ContractAPI) NewEventQuery() *index.EventQuery {
+	m.record("NewEventQuery")
+	if m.NewEventQueryFunc != nil {
+		return m.NewEventQueryFunc()
+	}
+	var r0 *index.EventQuery
+	return r0
+}
+
+func (m *ContractAPI) NewConstantQuery() *index.ConstantQuery {
+	m.record("NewConstantQuery")
+	if m.NewConstantQueryFunc != nil {
+		return m.NewConstantQueryFunc()
+	}
+	var r0 *index.ConstantQuery
+	return r0
+}
+
+func (m *ContractAPI) NewBigmapQuery() *index.BigmapQuery {
+	m.record("NewBigmapQuery")
+	if m.NewBigmapQueryFunc != nil {
+		return m.NewBigmapQueryFunc()
+	}
+	var r0 *index.BigmapQuery
+	return r0
+}
+
+func (m *ContractAPI) NewBigmapValueQuery() *index.BigmapValueQuery {
+	m.record("NewBigmapValueQuery")
+	if m.NewBigmapValueQueryFunc != nil {
+		return m.NewBigmapValueQueryFunc()
+	}
+	var r0 *index.BigmapValueQuery
+	return r0
+}
+
+func (m *ContractAPI) NewBigmapUpdateQuery() *index.BigmapUpdateQuery {
+	m.record("NewBigmapUpdateQuery")
+	if m.NewBigmapUpdateQueryFunc != nil {
+		return m.NewBigmapUpdateQueryFunc()
+	}
+	var r0 *index.BigmapUpdateQuery
+	return r0
+}
+
+// ExplorerAPI is a configurable fake of index.ExplorerAPI.
+type ExplorerAPI struct {
+	Recorder
+	GetStatusFunc       func(context.Context) (*index.Status, error)
+	GetTipFunc          func(context.Context) (*index.Tip, error)
+	GetConfigHeadFunc   func(context.Context) (*index.Config, error)
+	GetConfigHeightFunc func(context.Context, int64) (*index.Config, error)
+	ListProtocolsFunc   func(context.Context) ([]index.Deployment, error)
+	GetElectionFunc     func(context.Context, int) (*index.Election, error)
+	ListVotersFunc      func(context.Context, int, int) ([]index.Voter, error)
+	ListBallotsFunc     func(context.Context, int, int) (index.BallotList, error)
+	NewChainQueryFunc   func() *index.ChainQuery
+}
+
+func (m *ExplorerAPI) GetStatus(p0 context.Context) (*index.Status, error) {
+	m.record("GetStatus", p0)
+	if m.GetStatusFunc != nil {
+		return m.GetStatusFunc(p0)
+	}
+	var r0 *index.Status
+	return r0, notConfigured("ExplorerAPI.GetStatus")
+}
+
+func (m *ExplorerAPI) GetTip(p0 context.Context) (*index.Tip, error) {
+	m.record("GetTip", p0)
+	if m.GetTipFunc != nil {
+		return m.GetTipFunc(p0)
+	}
+	var r0 *index.Tip
+	return r0, notConfigured("ExplorerAPI.GetTip")
+}
+
+func (m *ExplorerAPI) GetConfigHead(p0 context.Context) (*index.Config, error) {
+	m.record("GetConfigHead", p0)
+	if m.GetConfigHeadFunc != nil {
+		return m.GetConfigHeadFunc(p0)
+	}
+	var r0 *index.Config
+	return r0, notConfigured("ExplorerAPI.GetConfigHead")
+}
+
+func (m *ExplorerAPI) GetConfigHeight(p0 context.Context, p1 int64) (*index.Config, error) {
+	m.record("GetConfigHeight", p0, p1)
+	if m.GetConfigHeightFunc != nil {
+		return m.GetConfigHeightFunc(p0, p1)
+	}
+	var r0 *index.Config
+	return r0, notConfigured("ExplorerAPI.GetConfigHeight")
+}
+
+func (m *ExplorerAPI) ListProtocols(p0 context.Context) ([]index.Deployment, error) {
+	m.record("ListProtocols", p0)
+	if m.ListProtocolsFunc != nil {
+		return m.ListProtocolsFunc(p0)
+	}
+	var r0 []index.Deployment
+	return r0, notConfigured("ExplorerAPI.ListProtocols")
+}
+
+func (m *ExplorerAPI) GetElection(p0 context.Context, p1 int) (*index.Election, error) {
+	m.record("GetElection", p0, p1)
+	if m.GetElectionFunc != nil {
+		return m.GetElectionFunc(p0, p1)
+	}
+	var r0 *index.Election
+	return r0, notConfigured("ExplorerAPI.GetElection")
+}
+
+func (m *ExplorerAPI) ListVoters(p0 context.Context, p1 int, p2 int) ([]index.Voter, error) {
+	m.record("ListVoters", p0, p1, p2)
+	if m.ListVotersFunc != nil {
+		return m.ListVotersFunc(p0, p1, p2)
+	}
+	var r0 []index.Voter
+	return r0, notConfigured("ExplorerAPI.ListVoters")
+}
+
+func (m *ExplorerAPI) ListBallots(p0 context.Context, p1 int, p2 int) (index.BallotList, error) {
+	m.record("ListBallots", p0, p1, p2)
+	if m.ListBallotsFunc != nil {
+		return m.ListBallotsFunc(p0, p1, p2)
+	}
+	var r0 index.BallotList
+	return r0, notConfigured("ExplorerAPI.ListBallots")
+}
+
+func (m *ExplorerAPI) NewChainQuery() *index.ChainQuery {
+	m.record("NewChainQuery")
+	if m.NewChainQueryFunc != nil {
+		return m.NewChainQueryFunc()
+	}
+	var r0 *index.ChainQuery
+	return r0
+}
+
+// MetadataAPI is a configurable fake of index.MetadataAPI.
+type MetadataAPI struct {
+	Recorder
+	ListFunc            func(context.Context) ([]index.Metadata, error)
+	GetWalletFunc       func(context.Context, index.Address) (index.Metadata, error)
+	CreateFunc          func(context.Context, []index.Metadata) ([]index.Metadata, error)
+	UpdateFunc          func(context.Context, index.Metadata) (index.Metadata, error)
+	PurgeFunc           func(context.Context) error
+	RemoveWalletFunc    func(context.Context, index.Address) error
+	DescribeAnyFunc     func(context.Context, string, string) (index.MetadataDescriptor, error)
+	DescribeAddressFunc func(context.Context, index.Address) (index.MetadataDescriptor, error)
+	GetSchemaFunc       func(context.Context, string) (json.RawMessage, error)
+	GetSchemasFunc      func(context.Context) (map[string]json.RawMessage, error)
+}
+
+func (m *MetadataAPI) List(p0 context.Context) ([]index.Metadata, error) {
+	m.record("List", p0)
+	if m.ListFunc != nil {
+		return m.ListFunc(p0)
+	}
+	var r0 []index.Metadata
+	return r0, notConfigured("MetadataAPI.List")
+}
+
+func (m *MetadataAPI) GetWallet(p0 context.Context, p1 index.Address) (index.Metadata, error) {
+	m.record("GetWallet", p0, p1)
+	if m.GetWalletFunc != nil {
+		return m.GetWalletFunc(p0, p1)
+	}
+	var r0 index.Metadata
+	return r0, notConfigured("MetadataAPI.GetWallet")
+}
+
+func (m *MetadataAPI) Create(p0 context.Context, p1 []index.Metadata) ([]index.Metadata, error) {
+	m.record("Create", p0, p1)
+	if m.CreateFunc != nil {
+		return m.CreateFunc(p0, p1)
+	}
+	var r0 []index.Metadata
+	return r0, notConfigured("MetadataAPI.Create")
+}
+
+func (m *MetadataAPI) Update(p0 context.Context, p1 index.Metadata) (index.Metadata, error) {
+	m.record("Update", p0, p1)
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(p0, p1)
+	}
+	var r0 index.Metadata
+	return r0, notConfigured("MetadataAPI.Update")
+}
+
+func (m *MetadataAPI) Purge(p0 context.Context) error {
+	m.record("Purge", p0)
+	if m.PurgeFunc != nil {
+		return m.PurgeFunc(p0)
+	}
+	return notConfigured("MetadataAPI.Purge")
+}
+
+func (m *MetadataAPI) RemoveWallet(p0 context.Context, p1 index.Address) error {
+	m.record("RemoveWallet", p0, p1)
+	if m.RemoveWalletFunc != nil {
+		return m.RemoveWalletFunc(p0, p1)
+	}
+	return notConfigured("MetadataAPI.RemoveWallet")
+}
+
+func (m *MetadataAPI) DescribeAny(p0 context.Context, p1 string, p2 string) (index.MetadataDescriptor, error) {
+	m.record("DescribeAny", p0, p1, p2)
+	if m.DescribeAnyFunc != nil {
+		return m.DescribeAnyFunc(p0, p1, p2)
+	}
+	var r0 index.MetadataDescriptor
+	return r0, notConfigured("MetadataAPI.DescribeAny")
+}
+
+func (m *MetadataAPI) DescribeAddress(p0 context.Context, p1 index.Address) (index.MetadataDescriptor, error) {
+	m.record("DescribeAddress", p0, p1)
+	if m.DescribeAddressFunc != nil {
+		return m.DescribeAddressFunc(p0, p1)
+	}
+	var r0 index.MetadataDescriptor
+	return r0, notConfigured("MetadataAPI.DescribeAddress")
+}
+
+func (m *MetadataAPI) GetSchema(p0 context.Context, p1 string) (json.RawMessage, error) {
+	m.record("GetSchema", p0, p1)
+	if m.GetSchemaFunc != nil {
+		return m.GetSchemaFunc(p0, p1)
+	}
+	var r0 json.RawMessage
+	return r0, notConfigured("MetadataAPI.GetSchema")
+}
+
+func (m *MetadataAPI) GetSchemas(p0 context.Context) (map[string]json.RawMessage, error) {
+	m.record("GetSchemas", p0)
+	if m.GetSchemasFunc != nil {
+		return m.GetSchemasFunc(p0)
+	}
+	var r0 map[string]json.RawMessage
+	return r0, notConfigured("MetadataAPI.GetSchemas")
+}
+
+// OpAPI is a configurable fake of index.OpAPI.
+type OpAPI struct {
+	Recorder
+	GetFunc          func(context.Context, index.OpHash, index.Query) (index.OpList, error)
+	ResolveTypesFunc func(context.Context, ...*index.Op) error
+	DecodeFunc       func(context.Context, ...*index.Op) error
+	NewQueryFunc     func() *index.OpQuery
+}
+
+func (m *OpAPI) Get(p0 context.Context, p1 index.OpHash, p2 index.Query) (index.OpList, error) {
+	m.record("Get", p0, p1, p2)
+	if m.GetFunc != nil {
+		return m.GetFunc(p0, p1, p2)
+	}
+	var r0 index.OpList
+	return r0, notConfigured("OpAPI.Get")
+}
+
+func (m *OpAPI) ResolveTypes(p0 context.Context, p1 ...*index.Op) error {
+	m.record("ResolveTypes", p0, p1)
+	if m.ResolveTypesFunc != nil {
+		return m.ResolveTypesFunc(p0, p1...)
+	}
+	return notConfigured("OpAPI.ResolveTypes")
+}
+
+func (m *OpAPI) Decode(p0 context.Context, p1 ...*index.Op) error {
+	m.record("Decode", p0, p1)
+	if m.DecodeFunc != nil {
+		return m.DecodeFunc(p0, p1...)
+	}
+	return notConfigured("OpAPI.Decode")
+}
+
+func (m *OpAPI) NewQuery() *index.OpQuery {
+	m.record("NewQuery")
+	if m.NewQueryFunc != nil {
+		return m.NewQueryFunc()
+	}
+	var r0 *index.OpQuery
+	return r0
+}
+
+// StatsAPI is a configurable fake of index.StatsAPI.
+type StatsAPI struct {
+	Recorder
+	GetAgeReportFunc      func(context.Context, index.Query) ([]*index.AgeReport, error)
+	GetSupplyReportFunc   func(context.Context, index.Query) ([]*index.SupplyReport, error)
+	GetAccountsReportFunc func(context.Context, index.Query) ([]*index.AccountsReport, error)
+	GetActivityReportFunc func(context.Context, index.Query) ([]*index.ActivityReport, error)
+	GetBalanceReportFunc  func(context.Context, index.Query) ([]*index.BalanceReport, error)
+	GetOpReportFunc       func(context.Context, index.Query) ([]*index.OpReport, error)
+}
+
+func (m *StatsAPI) GetAgeReport(p0 context.Context, p1 index.Query) ([]*index.AgeReport, error) {
+	m.record("GetAgeReport", p0, p1)
+	if m.GetAgeReportFunc != nil {
+		return m.GetAgeReportFunc(p0, p1)
+	}
+	var r0 []*index.AgeReport
+	return r0, notConfigured("StatsAPI.GetAgeReport")
+}
+
+func (m *StatsAPI) GetSupplyReport(p0 context.Context, p1 index.Query) ([]*index.SupplyReport, error) {
+	m.record("GetSupplyReport", p0, p1)
+	if m.GetSupplyReportFunc != nil {
+		return m.GetSupplyReportFunc(p0, p1)
+	}
+	var r0 []*index.SupplyReport
+	return r0, notConfigured("StatsAPI.GetSupplyReport")
+}
+
+func (m *StatsAPI) GetAccountsReport(p0 context.Context, p1 index.Query) ([]*index.AccountsReport, error) {
+	m.record("GetAccountsReport", p0, p1)
+	if m.GetAccountsReportFunc != nil {
+		return m.GetAccountsReportFunc(p0, p1)
+	}
+	var r0 []*index.AccountsReport
+	return r0, notConfigured("StatsAPI.GetAccountsReport")
+}
+
+func (m *StatsAPI) GetActivityReport(p0 context.Context, p1 index.Query) ([]*index.ActivityReport, error) {
+	m.record("GetActivityReport", p0, p1)
+	if m.GetActivityReportFunc != nil {
+		return m.GetActivityReportFunc(p0, p1)
+	}
+	var r0 []*index.ActivityReport
+	return r0, notConfigured("StatsAPI.GetActivityReport")
+}
+
+func (m *StatsAPI) GetBalanceReport(p0 context.Context, p1 index.Query) ([]*index.BalanceReport, error) {
+	m.record("GetBalanceReport", p0, p1)
+	if m.GetBalanceReportFunc != nil {
+		return m.GetBalanceReportFunc(p0, p1)
+	}
+	var r0 []*index.BalanceReport
+	return r0, notConfigured("StatsAPI.GetBalanceReport")
+}
+
+func (m *StatsAPI) GetOpReport(p0 context.Context, p1 index.Query) ([]*index.OpReport, error) {
+	m.record("GetOpReport", p0, p1)
+	if m.GetOpReportFunc != nil {
+		return m.GetOpReportFunc(p0, p1)
+	}
+	var r0 []*index.OpReport
+	return r0, notConfigured("StatsAPI.GetOpReport")
+}
+
+// IpfsAPI is a configurable fake of ipfs.IpfsAPI.
+type IpfsAPI struct {
+	Recorder
+	GetDataFunc  func(context.Context, string, any) error
+	GetImageFunc func(context.Context, string, string, io.Writer) error
+}
+
+func (m *IpfsAPI) GetData(p0 context.Context, p1 string, p2 any) error {
+	m.record("GetData", p0, p1, p2)
+	if m.GetDataFunc != nil {
+		return m.GetDataFunc(p0, p1, p2)
+	}
+	return notConfigured("IpfsAPI.GetData")
+}
+
+func (m *IpfsAPI) GetImage(p0 context.Context, p1 string, p2 string, p3 io.Writer) error {
+	m.record("GetImage", p0, p1, p2, p3)
+	if m.GetImageFunc != nil {
+		return m.GetImageFunc(p0, p1, p2, p3)
+	}
+	return notConfigured("IpfsAPI.GetImage")
+}
+
+// MarketAPI is a configurable fake of market.MarketAPI.
+type MarketAPI struct {
+	Recorder
+	GetTickerFunc   func(context.Context, string, string) (*market.Ticker, error)
+	ListTickersFunc func(context.Context) ([]market.Ticker, error)
+	ListCandlesFunc func(context.Context, market.CandleQuery) (market.CandleList, error)
+}
+
+func (m *MarketAPI) GetTicker(p0 context.Context, p1 string, p2 string) (*market.Ticker, error) {
+	m.record("GetTicker", p0, p1, p2)
+	if m.GetTickerFunc != nil {
+		return m.GetTickerFunc(p0, p1, p2)
+	}
+	var r0 *market.Ticker
+	return r0, notConfigured("MarketAPI.GetTicker")
+}
+
+func (m *MarketAPI) ListTickers(p0 context.Context) ([]market.Ticker, error) {
+	m.record("ListTickers", p0)
+	if m.ListTickersFunc != nil {
+		return m.ListTickersFunc(p0)
+	}
+	var r0 []market.Ticker
+	return r0, notConfigured("MarketAPI.ListTickers")
+}
+
+func (m *MarketAPI) ListCandles(p0 context.Context, p1 market.CandleQuery) (market.CandleList, error) {
+	m.record("ListCandles", p0, p1)
+	if m.ListCandlesFunc != nil {
+		return m.ListCandlesFunc(p0, p1)
+	}
+	var r0 market.CandleList
+	return r0, notConfigured("MarketAPI.ListCandles")
+}
+
+// NftAPI is a configurable fake of nft.NftAPI.
+type NftAPI struct {
+	Recorder
+	GetMarketFunc           func(context.Context, nft.Address) (*nft.NftMarket, error)
+	ListMarketEventsFunc    func(context.Context, nft.Address, nft.Query) ([]*nft.NftEvent, error)
+	ListMarketPositionsFunc func(context.Context, nft.Address, nft.Query) ([]*nft.NftPosition, error)
+	ListMarketTradesFunc    func(context.Context, nft.Address, nft.Query) ([]*nft.NftTrade, error)
+	ListMarketsFunc         func(context.Context, nft.Query) ([]*nft.NftMarket, error)
+	ListEventsFunc          func(context.Context, nft.Query) ([]*nft.NftEvent, error)
+	ListPositionsFunc       func(context.Context, nft.Query) ([]*nft.NftPosition, error)
+	ListTradesFunc          func(context.Context, nft.Query) ([]*nft.NftTrade, error)
+}
+
+func (m *NftAPI) GetMarket(p0 context.Context, p1 nft.Address) (*nft.NftMarket, error) {
+	m.record("GetMarket", p0, p1)
+	if m.GetMarketFunc != nil {
+		return m.GetMarketFunc(p0, p1)
+	}
+	var r0 *nft.NftMarket
+	return r0, notConfigured("NftAPI.GetMarket")
+}
+
+func (m *NftAPI) ListMarketEvents(p0 context.Context, p1 nft.Address, p2 nft.Query) ([]*nft.NftEvent, error) {
+	m.record("ListMarketEvents", p0, p1, p2)
+	if m.ListMarketEventsFunc != nil {
+		return m.ListMarketEventsFunc(p0, p1, p2)
+	}
+	var r0 []*nft.NftEvent
+	return r0, notConfigured("NftAPI.ListMarketEvents")
+}
+
+func (m *NftAPI) ListMarketPositions(p0 context.Context, p1 nft.Address, p2 nft.Query) ([]*nft.NftPosition, error) {
+	m.record("ListMarketPositions", p0, p1, p2)
+	if m.ListMarketPositionsFunc != nil {
+		return m.ListMarketPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*nft.NftPosition
+	return r0, notConfigured("NftAPI.ListMarketPositions")
+}
+
+func (m *NftAPI) ListMarketTrades(p0 context.Context, p1 nft.Address, p2 nft.Query) ([]*nft.NftTrade, error) {
+	m.record("ListMarketTrades", p0, p1, p2)
+	if m.ListMarketTradesFunc != nil {
+		return m.ListMarketTradesFunc(p0, p1, p2)
+	}
+	var r0 []*nft.NftTrade
+	return r0, notConfigured("NftAPI.ListMarketTrades")
+}
+
+func (m *NftAPI) ListMarkets(p0 context.Context, p1 nft.Query) ([]*nft.NftMarket, error) {
+	m.record("ListMarkets", p0, p1)
+	if m.ListMarketsFunc != nil {
+		return m.ListMarketsFunc(p0, p1)
+	}
+	var r0 []*nft.NftMarket
+	return r0, notConfigured("NftAPI.ListMarkets")
+}
+
+func (m *NftAPI) ListEvents(p0 context.Context, p1 nft.Query) ([]*nft.NftEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*nft.NftEvent
+	return r0, notConfigured("NftAPI.ListEvents")
+}
+
+func (m *NftAPI) ListPositions(p0 context.Context, p1 nft.Query) ([]*nft.NftPosition, error) {
+	m.record("ListPositions", p0, p1)
+	if m.ListPositionsFunc != nil {
+		return m.ListPositionsFunc(p0, p1)
+	}
+	var r0 []*nft.NftPosition
+	return r0, notConfigured("NftAPI.ListPositions")
+}
+
+func (m *NftAPI) ListTrades(p0 context.Context, p1 nft.Query) ([]*nft.NftTrade, error) {
+	m.record("ListTrades", p0, p1)
+	if m.ListTradesFunc != nil {
+		return m.ListTradesFunc(p0, p1)
+	}
+	var r0 []*nft.NftTrade
+	return r0, notConfigured("NftAPI.ListTrades")
+}
+
+// TokenAPI is a configurable fake of token.TokenAPI.
+type TokenAPI struct {
+	Recorder
+	GetTokenFunc           func(context.Context, token.TokenAddress) (*token.Token, error)
+	GetLedgerFunc          func(context.Context, token.Address) (*token.Ledger, error)
+	GetTokenMetadataFunc   func(context.Context, token.TokenAddress) (*token.TokenMetadata, error)
+	GetLedgerMetadataFunc  func(context.Context, token.Address) (*token.TokenMetadata, error)
+	ListLedgerTokensFunc   func(context.Context, token.Address, token.Query) ([]*token.Token, error)
+	ListLedgerEventsFunc   func(context.Context, token.Address, token.Query) ([]*token.TokenEvent, error)
+	ListLedgerBalancesFunc func(context.Context, token.Address, token.Query) ([]*token.TokenBalance, error)
+	ListTokenEventsFunc    func(context.Context, token.TokenAddress, token.Query) ([]*token.TokenEvent, error)
+	ListTokenBalancesFunc  func(context.Context, token.TokenAddress, token.Query) ([]*token.TokenBalance, error)
+	ListTokensFunc         func(context.Context, token.Query) ([]*token.Token, error)
+	ListEventsFunc         func(context.Context, token.Query) ([]*token.TokenEvent, error)
+	ListLedgersFunc        func(context.Context, token.Query) ([]*token.Ledger, error)
+	ListMetadataFunc       func(context.Context, token.Query) ([]*token.TokenMetadata, error)
+}
+
+func (m *TokenAPI) GetToken(p0 context.Context, p1 token.TokenAddress) (*token.Token, error) {
+	m.record("GetToken", p0, p1)
+	if m.GetTokenFunc != nil {
+		return m.GetTokenFunc(p0, p1)
+	}
+	var r0 *token.Token
+	return r0, notConfigured("TokenAPI.GetToken")
+}
+
+func (m *TokenAPI) GetLedger(p0 context.Context, p1 token.Address) (*token.Ledger, error) {
+	m.record("GetLedger", p0, p1)
+	if m.GetLedgerFunc != nil {
+		return m.GetLedgerFunc(p0, p1)
+	}
+	var r0 *token.Ledger
+	return r0, notConfigured("TokenAPI.GetLedger")
+}
+
+func (m *TokenAPI) GetTokenMetadata(p0 context.Context, p1 token.TokenAddress) (*token.TokenMetadata, error) {
+	m.record("GetTokenMetadata", p0, p1)
+	if m.GetTokenMetadataFunc != nil {
+		return m.GetTokenMetadataFunc(p0, p1)
+	}
+	var r0 *token.TokenMetadata
+	return r0, notConfigured("TokenAPI.GetTokenMetadata")
+}
+
+func (m *TokenAPI) GetLedgerMetadata(p0 context.Context, p1 token.Address) (*token.TokenMetadata, error) {
+	m.record("GetLedgerMetadata", p0, p1)
+	if m.GetLedgerMetadataFunc != nil {
+		return m.GetLedgerMetadataFunc(p0, p1)
+	}
+	var r0 *token.TokenMetadata
+	return r0, notConfigured("TokenAPI.GetLedgerMetadata")
+}
+
+func (m *TokenAPI) ListLedgerTokens(p0 context.Context, p1 token.Address, p2 token.Query) ([]*token.Token, error) {
+	m.record("ListLedgerTokens", p0, p1, p2)
+	if m.ListLedgerTokensFunc != nil {
+		return m.ListLedgerTokensFunc(p0, p1, p2)
+	}
+	var r0 []*token.Token
+	return r0, notConfigured("TokenAPI.ListLedgerTokens")
+}
+
+func (m *TokenAPI) ListLedgerEvents(p0 context.Context, p1 token.Address, p2 token.Query) ([]*token.TokenEvent, error) {
+	m.record("ListLedgerEvents", p0, p1, p2)
+	if m.ListLedgerEventsFunc != nil {
+		return m.ListLedgerEventsFunc(p0, p1, p2)
+	}
+	var r0 []*token.TokenEvent
+	return r0, notConfigured("TokenAPI.ListLedgerEvents")
+}
+
+func (m *TokenAPI) ListLedgerBalances(p0 context.Context, p1 token.Address, p2 token.Query) ([]*token.TokenBalance, error) {
+	m.record("ListLedgerBalances", p0, p1, p2)
+	if m.ListLedgerBalancesFunc != nil {
+		return m.ListLedgerBalancesFunc(p0, p1, p2)
+	}
+	var r0 []*token.TokenBalance
+	return r0, notConfigured("TokenAPI.ListLedgerBalances")
+}
+
+func (m *TokenAPI) ListTokenEvents(p0 context.Context, p1 token.TokenAddress, p2 token.Query) ([]*token.TokenEvent, error) {
+	m.record("ListTokenEvents", p0, p1, p2)
+	if m.ListTokenEventsFunc != nil {
+		return m.ListTokenEventsFunc(p0, p1, p2)
+	}
+	var r0 []*token.TokenEvent
+	return r0, notConfigured("TokenAPI.ListTokenEvents")
+}
+
+func (m *TokenAPI) ListTokenBalances(p0 context.Context, p1 token.TokenAddress, p2 token.Query) ([]*token.TokenBalance, error) {
+	m.record("ListTokenBalances", p0, p1, p2)
+	if m.ListTokenBalancesFunc != nil {
+		return m.ListTokenBalancesFunc(p0, p1, p2)
+	}
+	var r0 []*token.TokenBalance
+	return r0, notConfigured("TokenAPI.ListTokenBalances")
+}
+
+func (m *TokenAPI) ListTokens(p0 context.Context, p1 token.Query) ([]*token.Token, error) {
+	m.record("ListTokens", p0, p1)
+	if m.ListTokensFunc != nil {
+		return m.ListTokensFunc(p0, p1)
+	}
+	var r0 []*token.Token
+	return r0, notConfigured("TokenAPI.ListTokens")
+}
+
+func (m *TokenAPI) ListEvents(p0 context.Context, p1 token.Query) ([]*token.TokenEvent, error) {
+	m.record("ListEvents", p0, p1)
+	if m.ListEventsFunc != nil {
+		return m.ListEventsFunc(p0, p1)
+	}
+	var r0 []*token.TokenEvent
+	return r0, notConfigured("TokenAPI.ListEvents")
+}
+
+func (m *TokenAPI) ListLedgers(p0 context.Context, p1 token.Query) ([]*token.Ledger, error) {
+	m.record("ListLedgers", p0, p1)
+	if m.ListLedgersFunc != nil {
+		return m.ListLedgersFunc(p0, p1)
+	}
+	var r0 []*token.Ledger
+	return r0, notConfigured("TokenAPI.ListLedgers")
+}
+
+func (m *TokenAPI) ListMetadata(p0 context.Context, p1 token.Query) ([]*token.TokenMetadata, error) {
+	m.record("ListMetadata", p0, p1)
+	if m.ListMetadataFunc != nil {
+		return m.ListMetadataFunc(p0, p1)
+	}
+	var r0 []*token.TokenMetadata
+	return r0, notConfigured("TokenAPI.ListMetadata")
+}
+
+// WalletAPI is a configurable fake of wallet.WalletAPI.
+type WalletAPI struct {
+	Recorder
+	ListTokenBalancesFunc    func(context.Context, wallet.Address, wallet.Query) ([]*wallet.TokenBalance, error)
+	ListTokenEventsFunc      func(context.Context, wallet.Address, wallet.Query) ([]*wallet.TokenEvent, error)
+	ListDexEventsFunc        func(context.Context, wallet.Address, wallet.Query) ([]*wallet.DexEvent, error)
+	ListDexPositionsFunc     func(context.Context, wallet.Address, wallet.Query) ([]*wallet.DexPosition, error)
+	ListDexTradesFunc        func(context.Context, wallet.Address, wallet.Query) ([]*wallet.DexTrade, error)
+	ListFarmEventsFunc       func(context.Context, wallet.Address, wallet.Query) ([]*wallet.FarmEvent, error)
+	ListFarmPositionsFunc    func(context.Context, wallet.Address, wallet.Query) ([]*wallet.FarmPosition, error)
+	ListLendingEventsFunc    func(context.Context, wallet.Address, wallet.Query) ([]*wallet.LendingEvent, error)
+	ListLendingPositionsFunc func(context.Context, wallet.Address, wallet.Query) ([]*wallet.LendingPosition, error)
+	ListNftEventsFunc        func(context.Context, wallet.Address, wallet.Query) ([]*wallet.NftEvent, error)
+	ListNftPositionsFunc     func(context.Context, wallet.Address, wallet.Query) ([]*wallet.NftPosition, error)
+	ListNftTradesFunc        func(context.Context, wallet.Address, wallet.Query) ([]*wallet.NftTrade, error)
+	ListDomainsFunc          func(context.Context, wallet.Address, wallet.Query) ([]*wallet.Domain, error)
+	ListDomainEventsFunc     func(context.Context, wallet.Address, wallet.Query) ([]*wallet.DomainEvent, error)
+	GetProfileFunc           func(context.Context, wallet.Address) (*wallet.Profile, error)
+	ListProfileEventsFunc    func(context.Context, wallet.Address, wallet.Query) ([]*wallet.ProfileEvent, error)
+	ListProfileClaimsFunc    func(context.Context, wallet.Address, wallet.Query) ([]*wallet.ProfileClaim, error)
+}
+
+func (m *WalletAPI) ListTokenBalances(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.TokenBalance, error) {
+	m.record("ListTokenBalances", p0, p1, p2)
+	if m.ListTokenBalancesFunc != nil {
+		return m.ListTokenBalancesFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.TokenBalance
+	return r0, notConfigured("WalletAPI.ListTokenBalances")
+}
+
+func (m *WalletAPI) ListTokenEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.TokenEvent, error) {
+	m.record("ListTokenEvents", p0, p1, p2)
+	if m.ListTokenEventsFunc != nil {
+		return m.ListTokenEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.TokenEvent
+	return r0, notConfigured("WalletAPI.ListTokenEvents")
+}
+
+func (m *WalletAPI) ListDexEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.DexEvent, error) {
+	m.record("ListDexEvents", p0, p1, p2)
+	if m.ListDexEventsFunc != nil {
+		return m.ListDexEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.DexEvent
+	return r0, notConfigured("WalletAPI.ListDexEvents")
+}
+
+func (m *WalletAPI) ListDexPositions(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.DexPosition, error) {
+	m.record("ListDexPositions", p0, p1, p2)
+	if m.ListDexPositionsFunc != nil {
+		return m.ListDexPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.DexPosition
+	return r0, notConfigured("WalletAPI.ListDexPositions")
+}
+
+func (m *WalletAPI) ListDexTrades(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.DexTrade, error) {
+	m.record("ListDexTrades", p0, p1, p2)
+	if m.ListDexTradesFunc != nil {
+		return m.ListDexTradesFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.DexTrade
+	return r0, notConfigured("WalletAPI.ListDexTrades")
+}
+
+func (m *WalletAPI) ListFarmEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.FarmEvent, error) {
+	m.record("ListFarmEvents", p0, p1, p2)
+	if m.ListFarmEventsFunc != nil {
+		return m.ListFarmEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.FarmEvent
+	return r0, notConfigured("WalletAPI.ListFarmEvents")
+}
+
+func (m *WalletAPI) ListFarmPositions(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.FarmPosition, error) {
+	m.record("ListFarmPositions", p0, p1, p2)
+	if m.ListFarmPositionsFunc != nil {
+		return m.ListFarmPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.FarmPosition
+	return r0, notConfigured("WalletAPI.ListFarmPositions")
+}
+
+func (m *WalletAPI) ListLendingEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.LendingEvent, error) {
+	m.record("ListLendingEvents", p0, p1, p2)
+	if m.ListLendingEventsFunc != nil {
+		return m.ListLendingEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.LendingEvent
+	return r0, notConfigured("WalletAPI.ListLendingEvents")
+}
+
+func (m *WalletAPI) ListLendingPositions(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.LendingPosition, error) {
+	m.record("ListLendingPositions", p0, p1, p2)
+	if m.ListLendingPositionsFunc != nil {
+		return m.ListLendingPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.LendingPosition
+	return r0, notConfigured("WalletAPI.ListLendingPositions")
+}
+
+func (m *WalletAPI) ListNftEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.NftEvent, error) {
+	m.record("ListNftEvents", p0, p1, p2)
+	if m.ListNftEventsFunc != nil {
+		return m.ListNftEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.NftEvent
+	return r0, notConfigured("WalletAPI.ListNftEvents")
+}
+
+func (m *WalletAPI) ListNftPositions(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.NftPosition, error) {
+	m.record("ListNftPositions", p0, p1, p2)
+	if m.ListNftPositionsFunc != nil {
+		return m.ListNftPositionsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.NftPosition
+	return r0, notConfigured("WalletAPI.ListNftPositions")
+}
+
+func (m *WalletAPI) ListNftTrades(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.NftTrade, error) {
+	m.record("ListNftTrades", p0, p1, p2)
+	if m.ListNftTradesFunc != nil {
+		return m.ListNftTradesFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.NftTrade
+	return r0, notConfigured("WalletAPI.ListNftTrades")
+}
+
+func (m *WalletAPI) ListDomains(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.Domain, error) {
+	m.record("ListDomains", p0, p1, p2)
+	if m.ListDomainsFunc != nil {
+		return m.ListDomainsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.Domain
+	return r0, notConfigured("WalletAPI.ListDomains")
+}
+
+func (m *WalletAPI) ListDomainEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.DomainEvent, error) {
+	m.record("ListDomainEvents", p0, p1, p2)
+	if m.ListDomainEventsFunc != nil {
+		return m.ListDomainEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.DomainEvent
+	return r0, notConfigured("WalletAPI.ListDomainEvents")
+}
+
+func (m *WalletAPI) GetProfile(p0 context.Context, p1 wallet.Address) (*wallet.Profile, error) {
+	m.record("GetProfile", p0, p1)
+	if m.GetProfileFunc != nil {
+		return m.GetProfileFunc(p0, p1)
+	}
+	var r0 *wallet.Profile
+	return r0, notConfigured("WalletAPI.GetProfile")
+}
+
+func (m *WalletAPI) ListProfileEvents(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.ProfileEvent, error) {
+	m.record("ListProfileEvents", p0, p1, p2)
+	if m.ListProfileEventsFunc != nil {
+		return m.ListProfileEventsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.ProfileEvent
+	return r0, notConfigured("WalletAPI.ListProfileEvents")
+}
+
+func (m *WalletAPI) ListProfileClaims(p0 context.Context, p1 wallet.Address, p2 wallet.Query) ([]*wallet.ProfileClaim, error) {
+	m.record("ListProfileClaims", p0, p1, p2)
+	if m.ListProfileClaimsFunc != nil {
+		return m.ListProfileClaimsFunc(p0, p1, p2)
+	}
+	var r0 []*wallet.ProfileClaim
+	return r0, notConfigured("WalletAPI.ListProfileClaims")
+}
+
+// ZmqAPI is a configurable fake of zmq.ZmqAPI.
+type ZmqAPI struct {
+	Recorder
+	NewSubscriberFunc func(string, ...string) *zmq.Subscriber
+}
+
+func (m *ZmqAPI) NewSubscriber(p0 string, p1 ...string) *zmq.Subscriber {
+	m.record("NewSubscriber", p0, p1)
+	if m.NewSubscriberFunc != nil {
+		return m.NewSubscriberFunc(p0, p1...)
+	}
+	var r0 *zmq.Subscriber
+	return r0
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+// Package mock provides test doubles for all SDK API interfaces. Each fake
+// has one function field per interface method (e.g. OpAPI.GetFunc) that
+// is called when set. Calls to methods without a function return zero
+// values and ErrNotConfigured. All calls are recorded.
+//
+//	ops := &mock.OpAPI{
+//		GetFunc: func(ctx context.Context, h index.OpHash, q index.Query) (index.OpList, error) {
+//			return index.OpList{{Hash: h}}, nil
+//		},
+//	}
+//	c := &tzpro.Client{Op: ops}
+//	...
+//	ops.AssertCalled(t, "Get")
+//
+// Fakes are generated from the interface definitions, run go generate after
+// changing an API interface.
+package mock
+
+//go:generate go run ../../scripts/mockgen -src .. -out api_gen.go
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNotConfigured is returned by fake methods without function.
+var ErrNotConfigured = errors.New("mock: method not configured")
+
+func notConfigured(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotConfigured, method)
+}
+
+// Call is a recorded method call.
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// TB is the subset of testing.TB used by assertion helpers.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Recorder records calls to a fake. It is embedded in all fakes and safe
+// for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) record(method string, args ...any) {
+	r.mu.Lock()
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+	r.mu.Unlock()
+}
+
+// Calls returns all recorded calls in order. When methods are given only
+// calls to these methods are returned.
+func (r *Recorder) Calls(methods ...string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([]Call, 0, len(r.calls))
+	for _, c := range r.calls {
+		if len(methods) == 0 || contains(methods, c.Method) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// CallCount returns how often method was called.
+func (r *Recorder) CallCount(method string) int {
+	return len(r.Calls(method))
+}
+
+// Called returns true when method was called at least once.
+func (r *Recorder) Called(method string) bool {
+	return r.CallCount(method) > 0
+}
+
+// Reset clears all recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.calls = nil
+	r.mu.Unlock()
+}
+
+// AssertCalled fails the test when method was not called.
+func (r *Recorder) AssertCalled(t TB, method string) bool {
+	t.Helper()
+	if !r.Called(method) {
+		t.Errorf("mock: expected call to %s", method)
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled fails the test when method was called.
+func (r *Recorder) AssertNotCalled(t TB, method string) bool {
+	t.Helper()
+	if n := r.CallCount(method); n > 0 {
+		t.Errorf("mock: unexpected %d call(s) to %s", n, method)
+		return false
+	}
+	return true
+}
+
+// AssertCallCount fails the test when method was not called exactly n times.
+func (r *Recorder) AssertCallCount(t TB, method string, n int) bool {
+	t.Helper()
+	if c := r.CallCount(method); c != n {
+		t.Errorf("mock: expected %d call(s) to %s, got %d", n, method, c)
+		return false
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
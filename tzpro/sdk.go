@@ -18,10 +18,8 @@ import (
 	"blockwatch.cc/tzpro-go/tzpro/nft"
 	"blockwatch.cc/tzpro-go/tzpro/token"
 	"blockwatch.cc/tzpro-go/tzpro/wallet"
-
-	// "blockwatch.cc/tzpro-go/tzpro/zmq"
+	"blockwatch.cc/tzpro-go/tzpro/zmq"
 	"github.com/echa/log"
-	lru "github.com/hashicorp/golang-lru/v2"
 )
 
 var (
@@ -48,9 +46,10 @@ type Client struct {
 	Wallet   wallet.WalletAPI
 	Market   market.MarketAPI
 	Ipfs     ipfs.IpfsAPI
-	// Zmq      zmq.ZmqAPI
+	Zmq      zmq.ZmqAPI
 
 	client *client.Client
+	market *client.Client // custom market client
 }
 
 func NewClient(url string, httpClient *http.Client) *Client {
@@ -58,6 +57,18 @@ func NewClient(url string, httpClient *http.Client) *Client {
 		WithApiKey(os.Getenv("TZPRO_API_KEY")).
 		WithUserAgent("tzpro-go/v" + SdkVersion)
 
+	s := newClient(c)
+	s.Market = market.NewMarketAPI(c)
+	s.Ipfs = ipfs.NewIpfsAPI(
+		client.NewClient("https://ipfs.tzpro.io", httpClient).
+			WithApiKey(os.Getenv("TZPRO_API_KEY")).
+			WithUserAgent("tzpro-go/v" + SdkVersion).
+			WithTimeout(60 * time.Second),
+	)
+	return s
+}
+
+func newClient(c *client.Client) *Client {
 	return &Client{
 		Account:  index.NewAccountAPI(c),
 		Block:    index.NewBlockAPI(c),
@@ -75,16 +86,24 @@ func NewClient(url string, httpClient *http.Client) *Client {
 		Domain:   identity.NewDomainAPI(c),
 		Profile:  identity.NewProfileAPI(c),
 		Wallet:   wallet.NewWalletAPI(c),
-		Market:   market.NewMarketAPI(c),
-		Ipfs: ipfs.NewIpfsAPI(
-			client.NewClient("https://ipfs.tzpro.io", httpClient).
-				WithApiKey(os.Getenv("TZPRO_API_KEY")).
-				WithUserAgent("tzpro-go/v" + SdkVersion).
-				WithTimeout(60 * time.Second),
-		),
-		// Zmq:    zmq.NewZmqAPI(c),
-		client: c,
+		Zmq:      zmq.NewZmqAPI(c),
+		client:   c,
+	}
+}
+
+// Clone returns a client with the same settings that shares connections,
+// caches and quotas with s. Use it to derive clients with different
+// settings, e.g. s.Clone().WithApiKey(key), without affecting s. The IPFS
+// API and a market API with custom URL are shared as is.
+func (s *Client) Clone() *Client {
+	n := newClient(s.client.Clone())
+	n.Ipfs = s.Ipfs
+	if s.market != nil {
+		n.Market, n.market = s.Market, s.market
+	} else {
+		n.Market = market.NewMarketAPI(n.client)
 	}
+	return n
 }
 
 func (s *Client) WithHeader(key, value string) *Client {
@@ -107,6 +126,7 @@ func (s *Client) WithMarketUrl(url string) *Client {
 		WithApiKey(os.Getenv("TZPRO_API_KEY")).
 		WithUserAgent("tzpro-go/v" + SdkVersion)
 	s.Market = market.NewMarketAPI(c)
+	s.market = c
 	return s
 }
 
@@ -134,6 +154,65 @@ func (s *Client) WithRetry(num int, delay time.Duration) *Client {
 	return s
 }
 
+func (s *Client) WithRetryPolicy(p RetryPolicy) *Client {
+	s.client.WithRetryPolicy(p)
+	return s
+}
+
+func (s *Client) WithRateLimitRetry(num int, maxWait time.Duration) *Client {
+	s.client.WithRateLimitRetry(num, maxWait)
+	return s
+}
+
+func (s *Client) WithRateLimit(rps float64, burst int) *Client {
+	s.client.WithRateLimit(rps, burst)
+	return s
+}
+
+func (s *Client) WithMaxConcurrency(n int) *Client {
+	s.client.WithMaxConcurrency(n)
+	return s
+}
+
+func (s *Client) Use(mw ...Middleware) *Client {
+	s.client.Use(mw...)
+	return s
+}
+
+func (s *Client) WithEndpoints(urls ...string) *Client {
+	s.client.WithEndpoints(urls...)
+	return s
+}
+
+func (s *Client) WithEndpointPool(p *EndpointPool) *Client {
+	s.client.WithEndpointPool(p)
+	return s
+}
+
+func (s *Client) WithCircuitBreaker(b *CircuitBreaker) *Client {
+	s.client.WithCircuitBreaker(b)
+	return s
+}
+
+func (s *Client) WithCoalescing(enable bool) *Client {
+	s.client.WithCoalescing(enable)
+	return s
+}
+
+func (s *Client) WithResponseCache(c ResponseCache) *Client {
+	s.client.WithResponseCache(c)
+	return s
+}
+
+func (s *Client) SetFinalized(height int64) {
+	s.client.SetFinalized(height)
+}
+
+// NewFollower creates a block follower that uses the client's block API.
+func (s *Client) NewFollower() *Follower {
+	return index.NewFollower(s.Block)
+}
+
 func (s *Client) WithLogger(log log.Logger) *Client {
 	s.client.WithLogger(log)
 	return s
@@ -144,10 +223,14 @@ func (s *Client) WithCacheSize(sz int) *Client {
 	return s
 }
 
-func (s *Client) UseScriptCache(cache *lru.TwoQueueCache[Address, any]) {
+func (s *Client) UseScriptCache(cache ScriptCache) {
 	s.client.UseScriptCache(cache)
 }
 
+func (s Client) CacheStats() CacheStats {
+	return s.client.CacheStats()
+}
+
 func (s Client) Retries() int {
 	return s.client.Retries()
 }
@@ -156,6 +239,10 @@ func (s Client) RetryDelay() time.Duration {
 	return s.client.RetryDelay()
 }
 
+func (s Client) RateLimit() RateLimit {
+	return s.client.RateLimit()
+}
+
 func (s Client) CacheGet(key Address) (any, bool) {
 	return s.client.CacheGet(key)
 }
@@ -0,0 +1,25 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package tzpro
+
+import "testing"
+
+func TestWithUpdatesClient(t *testing.T) {
+	base := NewClient("https://api.tzpro.io", nil)
+	base.WithApiKey("base")
+	if got := base.client.DefaultHeaders().Get("X-Api-Key"); got != "base" {
+		t.Errorf("base api key is %q", got)
+	}
+
+	tenant := base.Clone().WithApiKey("tenant").WithMarketUrl("https://market.example.com")
+	if got := base.client.DefaultHeaders().Get("X-Api-Key"); got != "base" {
+		t.Errorf("base api key changed to %q", got)
+	}
+	if got := tenant.client.DefaultHeaders().Get("X-Api-Key"); got != "tenant" {
+		t.Errorf("tenant api key is %q", got)
+	}
+	if base.market != nil || tenant.market == nil {
+		t.Errorf("WithMarketUrl on the clone changed the base client")
+	}
+}
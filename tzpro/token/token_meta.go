@@ -33,7 +33,7 @@ func (c *tokenClient) ListMetadata(ctx context.Context, params Query) ([]*TokenM
 func (c *tokenClient) GetLedgerMetadata(ctx context.Context, addr Address) (*TokenMetadata, error) {
 	val := &TokenMetadata{}
 	u := fmt.Sprintf("/v1/meta/%s", addr)
-	if err := c.client.Get(ctx, u, nil, val); err != nil {
+	if err := c.client.GetShared(ctx, u, nil, val); err != nil {
 		return nil, err
 	}
 	return val, nil
@@ -42,7 +42,7 @@ func (c *tokenClient) GetLedgerMetadata(ctx context.Context, addr Address) (*Tok
 func (c *tokenClient) GetTokenMetadata(ctx context.Context, addr TokenAddress) (*TokenMetadata, error) {
 	val := &TokenMetadata{}
 	u := fmt.Sprintf("/v1/meta/%s", addr)
-	if err := c.client.Get(ctx, u, nil, val); err != nil {
+	if err := c.client.GetShared(ctx, u, nil, val); err != nil {
 		return nil, err
 	}
 	return val, nil
@@ -28,23 +28,68 @@ type (
 	ErrApi         = client.ErrApi
 	ErrHttp        = client.ErrHttp
 	ErrRateLimited = client.ErrRateLimited
+	StreamResponse = client.StreamResponse
+	RateLimit      = client.RateLimit
+	RetryPolicy    = client.RetryPolicy
+	BackoffPolicy  = client.BackoffPolicy
+	Handler        = client.Handler
+	Middleware     = client.Middleware
+	Response       = client.Response
+	EndpointPool   = client.EndpointPool
+	EndpointState  = client.EndpointState
+	BalanceMode    = client.BalanceMode
+	CircuitBreaker = client.CircuitBreaker
+	ErrCircuitOpen = client.ErrCircuitOpen
+	ResponseCache  = client.ResponseCache
+	CacheEntry     = client.CacheEntry
+	MemoryCache    = client.MemoryCache
+	DiskCache      = client.DiskCache
+	ScriptCache    = client.ScriptCache
+	CacheStats     = client.CacheStats
+	CallOption     = client.CallOption
+	DecodeError    = client.DecodeError
+	Follower       = index.Follower
+	FollowEvent    = index.FollowEvent
 )
 
 var (
-	NewAddress       = tezos.MustParseAddress
-	ParseAddress     = tezos.ParseAddress
-	NewPoolAddres    = defi.MustParsePoolAddress
-	ParsePoolAddress = defi.ParsePoolAddress
-	NewToken         = tezos.MustParseToken
-	NewQuery         = client.NewQuery
-	IsErrApi         = client.IsErrApi
-	IsErrHttp        = client.IsErrHttp
-	IsErrRateLimited = client.IsErrRateLimited
-	ErrorStatus      = client.ErrorStatus
+	NewAddress         = tezos.MustParseAddress
+	ParseAddress       = tezos.ParseAddress
+	NewPoolAddres      = defi.MustParsePoolAddress
+	ParsePoolAddress   = defi.ParsePoolAddress
+	NewToken           = tezos.MustParseToken
+	NewQuery           = client.NewQuery
+	IsErrApi           = client.IsErrApi
+	IsErrHttp          = client.IsErrHttp
+	IsErrRateLimited   = client.IsErrRateLimited
+	IsErrCircuitOpen   = client.IsErrCircuitOpen
+	ErrorStatus        = client.ErrorStatus
+	NewBackoffPolicy   = client.NewBackoffPolicy
+	NewEndpointPool    = client.NewEndpointPool
+	NewCircuitBreaker  = client.NewCircuitBreaker
+	NewMemoryCache     = client.NewMemoryCache
+	NewDiskCache       = client.NewDiskCache
+	NewFileScriptCache = index.NewFileScriptCache
+	WithCallOptions    = client.WithCallOptions
+	CallApiKey         = client.CallApiKey
+	CallHeader         = client.CallHeader
+	CallTimeout        = client.CallTimeout
+	CallRetryPolicy    = client.CallRetryPolicy
+	CallNoCache        = client.CallNoCache
 
 	NoQuery = NewQuery()
 )
 
+var (
+	ErrBadRequest        = client.ErrBadRequest
+	ErrUnauthorized      = client.ErrUnauthorized
+	ErrForbidden         = client.ErrForbidden
+	ErrNotFound          = client.ErrNotFound
+	ErrTooManyRequests   = client.ErrTooManyRequests
+	ErrServerUnavailable = client.ErrServerUnavailable
+	ErrReorgTooDeep      = index.ErrReorgTooDeep
+)
+
 const (
 	FillModeInvalid FillMode = ""
 	FillModeNone    FillMode = "none"
@@ -79,6 +124,11 @@ const (
 	OrderDesc OrderType = "desc"
 )
 
+const (
+	BalanceRoundRobin = client.BalanceRoundRobin
+	BalanceLatency    = client.BalanceLatency
+)
+
 const (
 	FormatJSON FormatType = "json"
 	FormatCSV  FormatType = "csv"
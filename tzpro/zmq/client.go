@@ -4,13 +4,11 @@
 package zmq
 
 import (
-	// "context"
-
 	"blockwatch.cc/tzpro-go/internal/client"
 )
 
 type ZmqAPI interface {
-	// DecodeOp(context.Context, *Message) (*Op, error)
+	NewSubscriber(addr string, topics ...string) *Subscriber
 }
 
 func NewZmqAPI(c *client.Client) ZmqAPI {
@@ -20,3 +18,8 @@ func NewZmqAPI(c *client.Client) ZmqAPI {
 type zmqClient struct {
 	client *client.Client
 }
+
+// NewSubscriber creates a subscriber that logs to the client's logger.
+func (c *zmqClient) NewSubscriber(addr string, topics ...string) *Subscriber {
+	return NewSubscriber(addr, topics...).WithLogger(c.client.Logger())
+}
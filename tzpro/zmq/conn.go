@@ -0,0 +1,342 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ZMTP 3.1 wire format, see https://rfc.zeromq.org/spec/37/
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+
+	greetingSize = 64
+)
+
+var (
+	// MaxFrameSize limits the size of frames accepted from a peer.
+	MaxFrameSize = 64 << 20
+
+	DefaultHandshakeTimeout = 10 * time.Second
+
+	ErrHandshake = errors.New("zmq: handshake failed")
+)
+
+// Conn is a ZMTP 3.x connection acting as SUB socket with NULL security.
+// Reads must happen from a single goroutine, writes are safe for concurrent
+// use.
+type Conn struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	wmu      sync.Mutex
+	minor    byte   // peer protocol minor version
+	peerType string // peer socket type
+	idle     time.Duration
+}
+
+// Dial connects to a ZMQ publisher at addr (tcp://host:port or host:port)
+// and performs the ZMTP handshake.
+func Dial(ctx context.Context, addr string) (*Conn, error) {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", strings.TrimPrefix(addr, "tcp://"))
+	if err != nil {
+		return nil, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultHandshakeTimeout)
+	}
+	nc.SetDeadline(deadline)
+	c, err := NewConn(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	nc.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// NewConn performs the ZMTP handshake on an established network connection.
+func NewConn(nc net.Conn) (*Conn, error) {
+	c := &Conn{
+		conn: nc,
+		r:    bufio.NewReader(nc),
+	}
+	if err := c.handshake(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Conn) handshake() error {
+	// greeting: signature, version 3.1, NULL mechanism, client role
+	var g [greetingSize]byte
+	g[0], g[9] = 0xff, 0x7f
+	g[10], g[11] = 3, 1
+	copy(g[12:32], "NULL")
+	if _, err := c.conn.Write(g[:]); err != nil {
+		return err
+	}
+	var peer [greetingSize]byte
+	if _, err := io.ReadFull(c.r, peer[:]); err != nil {
+		return err
+	}
+	if peer[0] != 0xff || peer[9]&0x01 != 0x01 {
+		return fmt.Errorf("%w: invalid signature", ErrHandshake)
+	}
+	if peer[10] < 3 {
+		return fmt.Errorf("%w: unsupported ZMTP version %d.%d", ErrHandshake, peer[10], peer[11])
+	}
+	c.minor = peer[11]
+	if peer[10] > 3 {
+		c.minor = 1
+	}
+	if mech := string(bytes.TrimRight(peer[12:32], "\x00")); mech != "NULL" {
+		return fmt.Errorf("%w: unsupported security mechanism %q", ErrHandshake, mech)
+	}
+
+	// exchange READY commands
+	if err := c.writeCommand("READY", property("Socket-Type", "SUB")); err != nil {
+		return err
+	}
+	name, body, err := c.readCommand()
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "READY":
+	case "ERROR":
+		return fmt.Errorf("%w: %s", ErrHandshake, errorReason(body))
+	default:
+		return fmt.Errorf("%w: unexpected command %q", ErrHandshake, name)
+	}
+	props, err := parseProperties(body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrHandshake, err)
+	}
+	c.peerType = props["socket-type"]
+	if c.peerType != "PUB" && c.peerType != "XPUB" {
+		return fmt.Errorf("%w: incompatible peer socket type %q", ErrHandshake, c.peerType)
+	}
+	return nil
+}
+
+// PeerType returns the socket type of the remote peer.
+func (c *Conn) PeerType() string {
+	return c.peerType
+}
+
+// Subscribe asks the publisher to send messages whose topic starts with
+// prefix. An empty prefix subscribes to all messages.
+func (c *Conn) Subscribe(prefix string) error {
+	return c.writeFrame(0, append([]byte{1}, prefix...))
+}
+
+// Unsubscribe cancels a previous subscription.
+func (c *Conn) Unsubscribe(prefix string) error {
+	return c.writeFrame(0, append([]byte{0}, prefix...))
+}
+
+// Ping sends a heartbeat. Peers reply with PONG which keeps the connection
+// busy while no messages are published. Peers that only speak ZMTP 3.0 do
+// not support heartbeats, Ping does nothing then.
+func (c *Conn) Ping(ttl time.Duration) error {
+	if c.minor < 1 {
+		return nil
+	}
+	var body [2]byte
+	binary.BigEndian.PutUint16(body[:], uint16(ttl/(100*time.Millisecond)))
+	return c.writeCommand("PING", body[:])
+}
+
+// SetIdleTimeout makes reads fail when no frame arrives within d. Heartbeat
+// replies count as traffic. Zero disables the timeout.
+func (c *Conn) SetIdleTimeout(d time.Duration) {
+	c.idle = d
+}
+
+// ReadMessage reads the next multipart message. Heartbeat commands are
+// handled internally.
+func (c *Conn) ReadMessage() ([][]byte, error) {
+	var parts [][]byte
+	for {
+		flags, body, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if flags&flagCommand > 0 {
+			if err := c.handleCommand(body); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		parts = append(parts, body)
+		if flags&flagMore == 0 {
+			return parts, nil
+		}
+	}
+}
+
+// Recv reads the next message. TzPro messages consist of a topic and a body
+// frame.
+func (c *Conn) Recv() (*Message, error) {
+	parts, err := c.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	m := &Message{topic: string(parts[0]), time: time.Now()}
+	if len(parts) > 1 {
+		m.body = parts[1]
+	}
+	return m, nil
+}
+
+// Close closes the network connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) handleCommand(body []byte) error {
+	name, data, err := splitCommand(body)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "PING":
+		// reply with the ping context
+		if len(data) < 2 {
+			return fmt.Errorf("zmq: malformed PING command")
+		}
+		return c.writeCommand("PONG", data[2:])
+	case "ERROR":
+		return fmt.Errorf("zmq: peer error: %s", errorReason(data))
+	default:
+		// PONG and unknown commands are ignored
+		return nil
+	}
+}
+
+func (c *Conn) readFrame() (byte, []byte, error) {
+	if c.idle > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.idle))
+	}
+	flags, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var size uint64
+	if flags&flagLong > 0 {
+		var b [8]byte
+		if _, err := io.ReadFull(c.r, b[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(b[:])
+	} else {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > uint64(MaxFrameSize) {
+		return 0, nil, fmt.Errorf("zmq: frame size %d exceeds limit", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
+
+func (c *Conn) readCommand() (string, []byte, error) {
+	flags, body, err := c.readFrame()
+	if err != nil {
+		return "", nil, err
+	}
+	if flags&flagCommand == 0 {
+		return "", nil, fmt.Errorf("%w: expected command frame", ErrHandshake)
+	}
+	return splitCommand(body)
+}
+
+func (c *Conn) writeCommand(name string, data []byte) error {
+	body := make([]byte, 0, 1+len(name)+len(data))
+	body = append(body, byte(len(name)))
+	body = append(body, name...)
+	body = append(body, data...)
+	return c.writeFrame(flagCommand, body)
+}
+
+func (c *Conn) writeFrame(flags byte, body []byte) error {
+	buf := make([]byte, 0, 9+len(body))
+	if len(body) > 255 {
+		buf = append(buf, flags|flagLong)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(len(body)))
+	} else {
+		buf = append(buf, flags, byte(len(body)))
+	}
+	buf = append(buf, body...)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+func splitCommand(body []byte) (string, []byte, error) {
+	if len(body) == 0 || len(body) < 1+int(body[0]) {
+		return "", nil, fmt.Errorf("zmq: malformed command")
+	}
+	n := int(body[0])
+	return string(body[1 : 1+n]), body[1+n:], nil
+}
+
+func errorReason(data []byte) string {
+	if len(data) == 0 || len(data) < 1+int(data[0]) {
+		return "unknown error"
+	}
+	return string(data[1 : 1+int(data[0])])
+}
+
+// property encodes a ZMTP metadata property.
+func property(name, value string) []byte {
+	buf := make([]byte, 0, 5+len(name)+len(value))
+	buf = append(buf, byte(len(name)))
+	buf = append(buf, name...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(value)))
+	return append(buf, value...)
+}
+
+// parseProperties decodes ZMTP metadata. Names are case-insensitive and
+// returned in lower case.
+func parseProperties(buf []byte) (map[string]string, error) {
+	props := make(map[string]string)
+	for len(buf) > 0 {
+		n := int(buf[0])
+		if len(buf) < 1+n+4 {
+			return nil, fmt.Errorf("malformed metadata")
+		}
+		name := strings.ToLower(string(buf[1 : 1+n]))
+		buf = buf[1+n:]
+		v := binary.BigEndian.Uint32(buf)
+		buf = buf[4:]
+		if uint64(len(buf)) < uint64(v) {
+			return nil, fmt.Errorf("malformed metadata")
+		}
+		props[name] = string(buf[:v])
+		buf = buf[v:]
+	}
+	return props, nil
+}
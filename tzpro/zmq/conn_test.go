@@ -0,0 +1,389 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pubGreeting is the greeting a publisher stand-in sends.
+type pubGreeting struct {
+	major, minor byte
+	mech         string
+}
+
+var zmtp31 = pubGreeting{3, 1, "NULL"}
+
+// acceptPub performs the publisher side of the handshake up to and
+// including the client's READY command. The reply is left to the caller.
+func acceptPub(nc net.Conn, g pubGreeting) (*Conn, error) {
+	p := &Conn{conn: nc, r: bufio.NewReader(nc)}
+	var buf [greetingSize]byte
+	if _, err := io.ReadFull(p.r, buf[:]); err != nil {
+		return nil, err
+	}
+	buf = [greetingSize]byte{}
+	buf[0], buf[9] = 0xff, 0x7f
+	buf[10], buf[11] = g.major, g.minor
+	copy(buf[12:32], g.mech)
+	buf[32] = 1 // as-server
+	if _, err := nc.Write(buf[:]); err != nil {
+		return nil, err
+	}
+	name, body, err := p.readCommand()
+	if err != nil {
+		return nil, err
+	}
+	props, err := parseProperties(body)
+	if err != nil {
+		return nil, err
+	}
+	if name != "READY" || props["socket-type"] != "SUB" {
+		return nil, fmt.Errorf("unexpected %s command with %v", name, props)
+	}
+	return p, nil
+}
+
+// servePub accepts a connection and replies with READY for socket type.
+func servePub(nc net.Conn, socketType string) (*Conn, error) {
+	p, err := acceptPub(nc, zmtp31)
+	if err != nil {
+		return nil, err
+	}
+	return p, p.writeCommand("READY", property("Socket-Type", socketType))
+}
+
+func (p *Conn) publish(topic, body string) error {
+	if err := p.writeFrame(flagMore, []byte(topic)); err != nil {
+		return err
+	}
+	return p.writeFrame(0, []byte(body))
+}
+
+// pipe runs serve as publisher on one end of a pipe and returns the client
+// end together with a channel that receives the result of serve.
+func pipe(t *testing.T, serve func(net.Conn) error) (net.Conn, <-chan error) {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- serve(server)
+	}()
+	return client, errc
+}
+
+func TestHandshake(t *testing.T) {
+	reason := func(s string) []byte {
+		return append([]byte{byte(len(s))}, s...)
+	}
+	tests := []struct {
+		name     string
+		greeting pubGreeting
+		reply    func(p *Conn) error
+		wantType string
+		wantErr  string
+	}{
+		{
+			name:     "pub",
+			greeting: zmtp31,
+			reply: func(p *Conn) error {
+				return p.writeCommand("READY", property("Socket-Type", "PUB"))
+			},
+			wantType: "PUB",
+		},
+		{
+			name:     "xpub",
+			greeting: zmtp31,
+			reply: func(p *Conn) error {
+				return p.writeCommand("READY", append(property("Identity", ""), property("socket-type", "XPUB")...))
+			},
+			wantType: "XPUB",
+		},
+		{
+			name:     "zmtp30",
+			greeting: pubGreeting{3, 0, "NULL"},
+			reply: func(p *Conn) error {
+				return p.writeCommand("READY", property("Socket-Type", "PUB"))
+			},
+			wantType: "PUB",
+		},
+		{
+			name:     "wrong_socket_type",
+			greeting: zmtp31,
+			reply: func(p *Conn) error {
+				return p.writeCommand("READY", property("Socket-Type", "REP"))
+			},
+			wantErr: `incompatible peer socket type "REP"`,
+		},
+		{
+			name:     "error",
+			greeting: zmtp31,
+			reply: func(p *Conn) error {
+				return p.writeCommand("ERROR", reason("access denied"))
+			},
+			wantErr: "access denied",
+		},
+		{
+			name:     "unexpected_command",
+			greeting: zmtp31,
+			reply: func(p *Conn) error {
+				return p.writeCommand("HELLO", nil)
+			},
+			wantErr: `unexpected command "HELLO"`,
+		},
+		{
+			name:     "malformed_metadata",
+			greeting: zmtp31,
+			reply: func(p *Conn) error {
+				return p.writeCommand("READY", []byte{11, 'S', 'o', 'c', 'k', 'e', 't', '-', 'T', 'y', 'p', 'e', 0, 0, 0, 9, 'P'})
+			},
+			wantErr: "malformed metadata",
+		},
+		{
+			name:     "mechanism",
+			greeting: pubGreeting{3, 1, "CURVE"},
+			wantErr:  `unsupported security mechanism "CURVE"`,
+		},
+		{
+			name:     "version",
+			greeting: pubGreeting{2, 0, "NULL"},
+			wantErr:  "unsupported ZMTP version 2.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nc, _ := pipe(t, func(nc net.Conn) error {
+				if tt.reply == nil {
+					// client gives up after the greeting
+					var buf [greetingSize]byte
+					if _, err := io.ReadFull(nc, buf[:]); err != nil {
+						return err
+					}
+					buf = [greetingSize]byte{}
+					buf[0], buf[9] = 0xff, 0x7f
+					buf[10], buf[11] = tt.greeting.major, tt.greeting.minor
+					copy(buf[12:32], tt.greeting.mech)
+					_, err := nc.Write(buf[:])
+					return err
+				}
+				p, err := acceptPub(nc, tt.greeting)
+				if err != nil {
+					return err
+				}
+				return tt.reply(p)
+			})
+			c, err := NewConn(nc)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrHandshake) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.PeerType() != tt.wantType {
+				t.Errorf("peer type %q, want %q", c.PeerType(), tt.wantType)
+			}
+		})
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	nc, errc := pipe(t, func(nc net.Conn) error {
+		p, err := servePub(nc, "PUB")
+		if err != nil {
+			return err
+		}
+		// client heartbeat with TTL in deciseconds
+		name, body, err := p.readCommand()
+		if err != nil {
+			return err
+		}
+		if name != "PING" || len(body) != 2 || body[0] != 0 || body[1] != 50 {
+			return fmt.Errorf("unexpected %s %x", name, body)
+		}
+		if err := p.writeCommand("PONG", nil); err != nil {
+			return err
+		}
+
+		// publisher heartbeat, the client must echo the context
+		if err := p.writeCommand("PING", []byte{0, 10, 'c', 't', 'x'}); err != nil {
+			return err
+		}
+		name, body, err = p.readCommand()
+		if err != nil {
+			return err
+		}
+		if name != "PONG" || string(body) != "ctx" {
+			return fmt.Errorf("unexpected %s %q", name, body)
+		}
+		return p.publish(TopicStatus, "[]")
+	})
+	c, err := NewConn(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(5 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	m, err := c.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Topic() != TopicStatus || string(m.Body()) != "[]" {
+		t.Errorf("got message %s %q", m.Topic(), m.Body())
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPingZMTP30(t *testing.T) {
+	nc, _ := pipe(t, func(nc net.Conn) error {
+		p, err := acceptPub(nc, pubGreeting{3, 0, "NULL"})
+		if err != nil {
+			return err
+		}
+		return p.writeCommand("READY", property("Socket-Type", "PUB"))
+	})
+	c, err := NewConn(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pipe blocks writes nobody reads, so a PING would hang here
+	done := make(chan error, 1)
+	go func() { done <- c.Ping(time.Second) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Ping sent a heartbeat to a ZMTP 3.0 peer")
+	}
+}
+
+func TestMaxFrameSize(t *testing.T) {
+	defer func(n int) { MaxFrameSize = n }(MaxFrameSize)
+	MaxFrameSize = 300
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{"short", 255, false},
+		{"long", 300, false},
+		{"too_large", 301, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nc, _ := pipe(t, func(nc net.Conn) error {
+				p, err := servePub(nc, "PUB")
+				if err != nil {
+					return err
+				}
+				return p.publish(TopicRawOp, strings.Repeat("x", tt.size))
+			})
+			c, err := NewConn(nc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := c.Recv()
+			switch {
+			case tt.wantErr && (err == nil || !strings.Contains(err.Error(), "exceeds limit")):
+				t.Fatalf("expected size error, got %v", err)
+			case !tt.wantErr && err != nil:
+				t.Fatal(err)
+			case !tt.wantErr && len(m.Body()) != tt.size:
+				t.Fatalf("got %d bytes, want %d", len(m.Body()), tt.size)
+			}
+		})
+	}
+}
+
+func TestSubscriberMatch(t *testing.T) {
+	tests := []struct {
+		topics []string
+		topic  string
+		want   bool
+	}{
+		{[]string{TopicRawBlock}, TopicRawBlock, true},
+		{[]string{TopicRawBlock}, TopicRawBlockRollback, false},
+		{[]string{TopicRawBlock, TopicRawOp}, TopicRawOp, true},
+		{[]string{TopicRawOp}, "raw_o", false},
+		{[]string{""}, TopicStatus, true},
+		{nil, TopicStatus, true},
+	}
+	for _, tt := range tests {
+		s := NewSubscriber("", tt.topics...)
+		if got := s.match(tt.topic); got != tt.want {
+			t.Errorf("topics %q match %q = %t, want %t", tt.topics, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestSubscriberReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// each connection publishes one message on a non-subscribed topic
+	// that shares a prefix, one matching message and then drops
+	go func() {
+		for i := 0; ; i++ {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			p, err := servePub(nc, "PUB")
+			if err == nil {
+				// consume the subscription so close does not reset the
+				// connection with unread data
+				p.readFrame()
+				p.publish(TopicRawBlockRollback, "rollback")
+				p.publish(TopicRawBlock, fmt.Sprint(i))
+			}
+			nc.Close()
+		}
+	}()
+
+	var got []string
+	errStop := errors.New("stop")
+	sub := NewSubscriber("tcp://"+ln.Addr().String(), TopicRawBlock).
+		WithHeartbeat(0).
+		WithReconnect(time.Millisecond, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = sub.Run(ctx, func(m *Message) error {
+		if m.Topic() != TopicRawBlock {
+			t.Errorf("received unsubscribed topic %s", m.Topic())
+		}
+		got = append(got, string(m.Body()))
+		if len(got) == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("Run returned %v, want handler error", err)
+	}
+	if strings.Join(got, ",") != "0,1,2" {
+		t.Errorf("got messages %q from successive connections", got)
+	}
+}
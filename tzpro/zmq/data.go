@@ -3,6 +3,23 @@
 
 package zmq
 
+const (
+	TopicRawBlock         = "raw_block"
+	TopicRawBlockRollback = "raw_block/rollback"
+	TopicRawOp            = "raw_op"
+	TopicRawOpRollback    = "raw_op/rollback"
+	TopicStatus           = "status"
+)
+
+// AllTopics lists all topics published by TzPro.
+var AllTopics = []string{
+	TopicRawBlock,
+	TopicRawBlockRollback,
+	TopicRawOp,
+	TopicRawOpRollback,
+	TopicStatus,
+}
+
 func Fields(topic string) []string {
 	switch topic {
 	case "raw_block", "raw_block/rollback":
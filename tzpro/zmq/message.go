@@ -4,6 +4,9 @@
 package zmq
 
 import (
+	"strings"
+	"time"
+
 	"blockwatch.cc/tzpro-go/internal/client"
 )
 
@@ -11,10 +14,45 @@ type Message struct {
 	topic  string
 	body   []byte
 	fields []string
+	time   time.Time
 }
 
 func NewMessage(topic, body []byte) *Message {
-	return &Message{string(topic), body, nil}
+	return &Message{topic: string(topic), body: body}
+}
+
+// WithFields sets the column layout of the message body. It is required
+// when a subscription was configured with custom columns.
+func (m *Message) WithFields(fields []string) *Message {
+	m.fields = fields
+	return m
+}
+
+// Fields returns the column layout of the message body.
+func (m *Message) Fields() []string {
+	if m.fields != nil {
+		return m.fields
+	}
+	return Fields(m.topic)
+}
+
+func (m *Message) Topic() string {
+	return m.topic
+}
+
+func (m *Message) Body() []byte {
+	return m.body
+}
+
+// Time returns when the message was received. Replayed messages carry the
+// original receive time.
+func (m *Message) Time() time.Time {
+	return m.time
+}
+
+// IsRollback returns true for messages on rollback topics.
+func (m *Message) IsRollback() bool {
+	return strings.HasSuffix(m.topic, "/rollback")
 }
 
 func (m *Message) DecodeOpHash() (OpHash, error) {
@@ -25,29 +63,29 @@ func (m *Message) DecodeBlockHash() (BlockHash, error) {
 	return ParseBlockHash(string(m.body))
 }
 
+// DecodeOp decodes a raw_op message. Decoding is strict, columns without
+// matching struct field fail. Use a Router for streams that may publish
+// columns the SDK does not know about.
 func (m *Message) DecodeOp() (*Op, error) {
-	o := new(Op)
-	err := client.Decode(m.body, ZmqRawOpColumns, o)
-	if err != nil {
-		return nil, err
-	}
-	return o, nil
+	return decodeMessage[Op](m, client.Decode)
 }
 
+// DecodeBlock decodes a raw_block message with strict column matching.
 func (m *Message) DecodeBlock() (*Block, error) {
-	b := new(Block)
-	err := client.Decode(m.body, ZmqRawBlockColumns, b)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
+	return decodeMessage[Block](m, client.Decode)
 }
 
+// DecodeStatus decodes a status message with strict column matching.
 func (m *Message) DecodeStatus() (*Status, error) {
-	s := new(Status)
-	err := client.Decode(m.body, ZmqStatusColumns, s)
-	if err != nil {
+	return decodeMessage[Status](m, client.Decode)
+}
+
+type decodeFunc func(buf []byte, fields []string, val any) error
+
+func decodeMessage[T any](m *Message, decode decodeFunc) (*T, error) {
+	v := new(T)
+	if err := decode(m.body, m.Fields(), v); err != nil {
 		return nil, err
 	}
-	return s, nil
+	return v, nil
 }
@@ -0,0 +1,435 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"blockwatch.cc/tzpro-go/internal/client"
+)
+
+// Stream files store messages in receive order. A file starts with a
+// header followed by records of
+//
+//	uvarint  microseconds since the previous record (unix time for the first)
+//	uvarint  block height (last known height for messages without one)
+//	uvarint  topic length, topic
+//	uvarint  body length, body
+//
+// Files are append-only. A record torn by a crash is dropped when the file
+// is opened for recording again.
+var streamMagic = []byte("TZPROZMQ\x01")
+
+var ErrInvalidStream = errors.New("zmq: invalid stream file")
+
+type record struct {
+	micros int64
+	height int64
+	topic  string
+	body   []byte
+}
+
+// Recorder writes received messages to a stream file.
+//
+//	rec, err := zmq.OpenRecorder("stream.zmq")
+//	defer rec.Close()
+//	err = sub.Run(ctx, rec.Wrap(handle))
+type Recorder struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	f      *os.File
+	last   int64 // unix micros of the last record
+	height int64
+	fields map[string][]string
+}
+
+// NewRecorder writes a new stream to w.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	r := &Recorder{
+		w:      bufio.NewWriter(w),
+		fields: make(map[string][]string),
+	}
+	if _, err := r.w.Write(streamMagic); err != nil {
+		return nil, err
+	}
+	return r, r.w.Flush()
+}
+
+// OpenRecorder creates a stream file or appends to an existing one.
+func OpenRecorder(name string) (*Recorder, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Size() == 0 {
+		r, err := NewRecorder(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		r.f = f
+		return r, nil
+	}
+
+	// find the end of the last complete record
+	p, err := NewPlayer(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	end := int64(len(streamMagic))
+	for {
+		rec, err := p.read()
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			if err := f.Truncate(end); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		end += int64(rec.size(p.last))
+		p.last, p.height = rec.micros, rec.height
+	}
+	if _, err := f.Seek(end, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Recorder{
+		w:      bufio.NewWriter(f),
+		f:      f,
+		last:   p.last,
+		height: p.height,
+		fields: make(map[string][]string),
+	}, nil
+}
+
+// WithFields sets the column layout of messages on topic, see Router.
+// Layouts are used to find block heights.
+func (r *Recorder) WithFields(topic string, fields []string) *Recorder {
+	r.fields[topic] = fields
+	return r
+}
+
+// Record appends m to the stream. Messages without receive time are stored
+// with the current time.
+func (r *Recorder) Record(m *Message) error {
+	t := m.time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	micros := t.UnixMicro()
+	if micros < r.last {
+		// keep time monotonic when the clock steps back
+		micros = r.last
+	}
+	if h := r.messageHeight(m); h > 0 {
+		r.height = h
+	}
+	rec := record{
+		micros: micros,
+		height: r.height,
+		topic:  m.topic,
+		body:   m.body,
+	}
+	if _, err := r.w.Write(rec.encode(nil, r.last)); err != nil {
+		return err
+	}
+	r.last = micros
+	return r.w.Flush()
+}
+
+// Wrap returns a message handler for Subscriber.Run that records messages
+// before passing them to fn. fn may be nil.
+func (r *Recorder) Wrap(fn func(*Message) error) func(*Message) error {
+	return func(m *Message) error {
+		if err := r.Record(m); err != nil {
+			return err
+		}
+		if fn == nil {
+			return nil
+		}
+		return fn(m)
+	}
+}
+
+// Close flushes the stream and closes the file opened by OpenRecorder.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.w.Flush()
+	if r.f != nil {
+		if cerr := r.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+type heightRow struct {
+	Height int64 `json:"height"`
+}
+
+func (r *Recorder) messageHeight(m *Message) int64 {
+	switch m.topic {
+	case TopicRawBlock, TopicRawBlockRollback, TopicRawOp, TopicRawOpRollback:
+	default:
+		return 0
+	}
+	fields := m.fields
+	if fields == nil {
+		fields = r.fields[m.topic]
+	}
+	if fields == nil {
+		fields = r.fields[strings.TrimSuffix(m.topic, "/rollback")]
+	}
+	if fields == nil {
+		fields = Fields(m.topic)
+	}
+	var row heightRow
+	if err := client.DecodeLenient(m.body, fields, &row); err != nil {
+		return 0
+	}
+	return row.Height
+}
+
+func (r record) encode(buf []byte, last int64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(r.micros-last))
+	buf = binary.AppendUvarint(buf, uint64(r.height))
+	buf = binary.AppendUvarint(buf, uint64(len(r.topic)))
+	buf = append(buf, r.topic...)
+	buf = binary.AppendUvarint(buf, uint64(len(r.body)))
+	return append(buf, r.body...)
+}
+
+func (r record) size(last int64) int {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], uint64(r.micros-last))
+	n += binary.PutUvarint(b[:], uint64(r.height))
+	n += binary.PutUvarint(b[:], uint64(len(r.topic)))
+	n += binary.PutUvarint(b[:], uint64(len(r.body)))
+	return n + len(r.topic) + len(r.body)
+}
+
+// Player replays a stream file. It implements the same Run method as
+// Subscriber, so recorded streams can be fed into a Router.
+//
+//	p, err := zmq.OpenPlayer("stream.zmq")
+//	defer p.Close()
+//	err = p.WithSpeed(10).SeekHeight(5_000_000)
+//	err = router.Run(ctx, p)
+type Player struct {
+	r       *bufio.Reader
+	c       io.Closer
+	speed   float64
+	last    int64 // unix micros of the last record read
+	height  int64
+	pending *record
+	after   func(time.Duration) <-chan time.Time // replay clock
+}
+
+// NewPlayer reads a stream from r.
+func NewPlayer(r io.Reader) (*Player, error) {
+	p := &Player{
+		r:     bufio.NewReader(r),
+		speed: 1,
+		after: time.After,
+	}
+	magic := make([]byte, len(streamMagic))
+	if _, err := io.ReadFull(p.r, magic); err != nil {
+		return nil, ErrInvalidStream
+	}
+	if !bytes.Equal(magic, streamMagic) {
+		return nil, ErrInvalidStream
+	}
+	return p, nil
+}
+
+// OpenPlayer opens a stream file for replay.
+func OpenPlayer(name string) (*Player, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewPlayer(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	p.c = f
+	return p, nil
+}
+
+// WithSpeed sets the replay speed relative to the original pace, e.g. 10
+// replays ten times faster. Zero replays without delays.
+func (p *Player) WithSpeed(f float64) *Player {
+	p.speed = f
+	return p
+}
+
+// SeekHeight skips messages recorded before the chain reached height. The
+// next message is the first one at or above height. Seeking backwards is
+// not supported.
+func (p *Player) SeekHeight(height int64) error {
+	for {
+		rec, err := p.next()
+		if err != nil {
+			return err
+		}
+		if rec.height >= height {
+			p.pending = rec
+			return nil
+		}
+	}
+}
+
+// Height returns the block height at the time the last message was
+// received.
+func (p *Player) Height() int64 {
+	return p.height
+}
+
+// Next returns the next message or io.EOF at the end of the stream.
+func (p *Player) Next() (*Message, error) {
+	rec, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return rec.message(), nil
+}
+
+// Run calls fn for each message until the end of the stream, ctx is
+// canceled or fn returns an error. Messages are delivered at the recorded
+// pace scaled by the replay speed.
+func (p *Player) Run(ctx context.Context, fn func(*Message) error) error {
+	var prev int64
+	for {
+		rec, err := p.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if prev > 0 && p.speed > 0 {
+			if d := time.Duration(float64(rec.micros-prev) / p.speed * float64(time.Microsecond)); d > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-p.after(d):
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		prev = rec.micros
+		if err := fn(rec.message()); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the file opened by OpenPlayer.
+func (p *Player) Close() error {
+	if p.c == nil {
+		return nil
+	}
+	return p.c.Close()
+}
+
+func (p *Player) next() (*record, error) {
+	rec := p.pending
+	if rec != nil {
+		p.pending = nil
+		return rec, nil
+	}
+	rec, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	p.last, p.height = rec.micros, rec.height
+	return rec, nil
+}
+
+// read decodes the next record relative to the last one. It returns io.EOF
+// at the end of the stream and io.ErrUnexpectedEOF for a torn record.
+func (p *Player) read() (*record, error) {
+	delta, err := binary.ReadUvarint(p.r)
+	if err != nil {
+		return nil, err
+	}
+	rec := &record{micros: p.last + int64(delta)}
+	height, err := p.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	rec.height = int64(height)
+	topic, err := p.bytes()
+	if err != nil {
+		return nil, err
+	}
+	rec.topic = string(topic)
+	if rec.body, err = p.bytes(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func (p *Player) uvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(p.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func (p *Player) bytes() ([]byte, error) {
+	n, err := p.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(MaxFrameSize) {
+		return nil, fmt.Errorf("%w: record size %d exceeds limit", ErrInvalidStream, n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(p.r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (r *record) message() *Message {
+	return &Message{
+		topic: r.topic,
+		body:  r.body,
+		time:  time.UnixMicro(r.micros),
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var recordStart = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// testMessage returns a message received at offset after recordStart.
+// Block messages carry their height as single column.
+func testMessage(topic string, height int64, offset time.Duration) *Message {
+	m := &Message{topic: topic, time: recordStart.Add(offset)}
+	if height > 0 {
+		m.body = []byte(fmt.Sprintf("[%d]", height))
+		m.fields = []string{"height"}
+	} else {
+		m.body = []byte(`"oo1"`)
+	}
+	return m
+}
+
+// testStream is a stream with status and op messages between blocks.
+func testStream() []*Message {
+	return []*Message{
+		testMessage(TopicStatus, 0, 0),
+		testMessage(TopicRawBlock, 10, time.Second),
+		testMessage(TopicStatus, 0, 1500*time.Millisecond),
+		testMessage(TopicRawBlock, 11, 3*time.Second),
+		testMessage(TopicRawBlockRollback, 11, 4*time.Second),
+		testMessage(TopicRawBlock, 11, 7*time.Second),
+		testMessage(TopicStatus, 0, 7*time.Second+time.Microsecond),
+	}
+}
+
+func recordAll(t *testing.T, rec *Recorder, msgs []*Message) {
+	t.Helper()
+	for _, m := range msgs {
+		if err := rec.Record(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readAll(t *testing.T, p *Player) []string {
+	t.Helper()
+	var got []string
+	for {
+		m, err := p.Next()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%s %s %s h=%d", m.Time().UTC().Format("15:04:05.000000"), m.Topic(), m.Body(), p.Height()))
+	}
+}
+
+func TestRecordRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recordAll(t, rec, testStream())
+
+	// the clock stepping back must not break the stream
+	recordAll(t, rec, []*Message{testMessage(TopicStatus, 0, 6*time.Second)})
+
+	p, err := NewPlayer(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"03:04:05.000000 status \"oo1\" h=0",
+		"03:04:06.000000 raw_block [10] h=10",
+		"03:04:06.500000 status \"oo1\" h=10",
+		"03:04:08.000000 raw_block [11] h=11",
+		"03:04:09.000000 raw_block/rollback [11] h=11",
+		"03:04:12.000000 raw_block [11] h=11",
+		"03:04:12.000001 status \"oo1\" h=11",
+		"03:04:12.000001 status \"oo1\" h=11",
+	}
+	if got := readAll(t, p); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replayed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRecordInvalid(t *testing.T) {
+	for _, data := range []string{"", "TZPRO", "TZPROZMQ\x02", "something else"} {
+		if _, err := NewPlayer(strings.NewReader(data)); !errors.Is(err, ErrInvalidStream) {
+			t.Errorf("%q: got %v, want ErrInvalidStream", data, err)
+		}
+	}
+	name := filepath.Join(t.TempDir(), "bad.zmq")
+	os.WriteFile(name, []byte("not a stream"), 0o644)
+	if _, err := OpenRecorder(name); !errors.Is(err, ErrInvalidStream) {
+		t.Errorf("OpenRecorder: got %v, want ErrInvalidStream", err)
+	}
+}
+
+func TestRecordAppendAfterTornWrite(t *testing.T) {
+	msgs := testStream()
+	for _, cut := range []int{1, 2, 5, 9} {
+		t.Run(fmt.Sprint("cut_", cut), func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "stream.zmq")
+			rec, err := OpenRecorder(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recordAll(t, rec, msgs[:4])
+			if err := rec.Close(); err != nil {
+				t.Fatal(err)
+			}
+			fi, _ := os.Stat(name)
+			complete := fi.Size()
+
+			// simulate a crash in the middle of writing the next record
+			var torn bytes.Buffer
+			tr, _ := NewRecorder(&torn)
+			recordAll(t, tr, msgs[4:5])
+			tail := torn.Bytes()[len(streamMagic):]
+			f, _ := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+			f.Write(tail[:cut])
+			f.Close()
+
+			// reopen drops the torn record and continues after the last
+			// complete one with correct time deltas and heights
+			rec, err = OpenRecorder(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi, _ := os.Stat(name); fi.Size() != complete {
+				t.Errorf("file size %d after reopen, want %d", fi.Size(), complete)
+			}
+			recordAll(t, rec, msgs[4:])
+			rec.Close()
+
+			p, err := OpenPlayer(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			var full bytes.Buffer
+			fr, _ := NewRecorder(&full)
+			recordAll(t, fr, msgs)
+			fp, _ := NewPlayer(&full)
+			got, want := readAll(t, p), readAll(t, fp)
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("replayed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestPlayerSeekHeight(t *testing.T) {
+	tests := []struct {
+		height  int64
+		want    string // first message after seek
+		wantErr error
+	}{
+		{0, "status", nil},
+		{10, "raw_block [10]", nil},
+		{11, "raw_block [11]", nil},
+		{12, "", io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.height), func(t *testing.T) {
+			var buf bytes.Buffer
+			rec, _ := NewRecorder(&buf)
+			recordAll(t, rec, testStream())
+			p, _ := NewPlayer(&buf)
+			if err := p.SeekHeight(tt.height); err != tt.wantErr {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			var got []string
+			err := p.WithSpeed(0).Run(context.Background(), func(m *Message) error {
+				got = append(got, strings.TrimSpace(m.Topic()+" "+string(m.Body())))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) == 0 || !strings.HasPrefix(got[0], tt.want) {
+				t.Errorf("first message after seek %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeClock records replay delays and fires immediately.
+type fakeClock []time.Duration
+
+func (c *fakeClock) after(d time.Duration) <-chan time.Time {
+	*c = append(*c, d)
+	ch := make(chan time.Time, 1)
+	ch <- recordStart
+	return ch
+}
+
+func TestPlayerSpeed(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  []time.Duration
+	}{
+		{1, []time.Duration{time.Second, 500 * time.Millisecond, 1500 * time.Millisecond, time.Second, 3 * time.Second, time.Microsecond}},
+		{2, []time.Duration{500 * time.Millisecond, 250 * time.Millisecond, 750 * time.Millisecond, 500 * time.Millisecond, 1500 * time.Millisecond, 500 * time.Nanosecond}},
+		{0.5, []time.Duration{2 * time.Second, time.Second, 3 * time.Second, 2 * time.Second, 6 * time.Second, 2 * time.Microsecond}},
+		{0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.speed), func(t *testing.T) {
+			var buf bytes.Buffer
+			rec, _ := NewRecorder(&buf)
+			recordAll(t, rec, testStream())
+			p, _ := NewPlayer(&buf)
+			var clock fakeClock
+			p.after = clock.after
+			var n int
+			if err := p.WithSpeed(tt.speed).Run(context.Background(), func(*Message) error {
+				n++
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if n != len(testStream()) {
+				t.Errorf("delivered %d messages", n)
+			}
+			if fmt.Sprint(clock) != fmt.Sprint(tt.want) {
+				t.Errorf("delays %v, want %v", clock, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayerCancel(t *testing.T) {
+	var buf bytes.Buffer
+	rec, _ := NewRecorder(&buf)
+	recordAll(t, rec, testStream())
+	p, _ := NewPlayer(&buf)
+
+	// cancel while waiting for the next message
+	ctx, cancel := context.WithCancel(context.Background())
+	p.after = func(time.Duration) <-chan time.Time {
+		cancel()
+		return make(chan time.Time)
+	}
+	var n int
+	err := p.Run(ctx, func(*Message) error {
+		n++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || n != 1 {
+		t.Errorf("got %v after %d messages, want context.Canceled after 1", err, n)
+	}
+}
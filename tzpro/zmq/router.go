@@ -0,0 +1,206 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"blockwatch.cc/tzpro-go/internal/client"
+)
+
+// Handler receives typed stream events. Rollback events carry the block or
+// operation that was removed from the chain by a reorg.
+type Handler interface {
+	OnBlock(context.Context, *Block) error
+	OnRollbackBlock(context.Context, *Block) error
+	OnOp(context.Context, *Op) error
+	OnRollbackOp(context.Context, *Op) error
+	OnStatus(context.Context, *Status) error
+}
+
+// HandlerFuncs implements Handler with optional functions. Events without
+// function are ignored.
+type HandlerFuncs struct {
+	Block         func(context.Context, *Block) error
+	RollbackBlock func(context.Context, *Block) error
+	Op            func(context.Context, *Op) error
+	RollbackOp    func(context.Context, *Op) error
+	Status        func(context.Context, *Status) error
+}
+
+var _ Handler = (*HandlerFuncs)(nil)
+
+func (h *HandlerFuncs) OnBlock(ctx context.Context, b *Block) error {
+	if h.Block == nil {
+		return nil
+	}
+	return h.Block(ctx, b)
+}
+
+func (h *HandlerFuncs) OnRollbackBlock(ctx context.Context, b *Block) error {
+	if h.RollbackBlock == nil {
+		return nil
+	}
+	return h.RollbackBlock(ctx, b)
+}
+
+func (h *HandlerFuncs) OnOp(ctx context.Context, o *Op) error {
+	if h.Op == nil {
+		return nil
+	}
+	return h.Op(ctx, o)
+}
+
+func (h *HandlerFuncs) OnRollbackOp(ctx context.Context, o *Op) error {
+	if h.RollbackOp == nil {
+		return nil
+	}
+	return h.RollbackOp(ctx, o)
+}
+
+func (h *HandlerFuncs) OnStatus(ctx context.Context, s *Status) error {
+	if h.Status == nil {
+		return nil
+	}
+	return h.Status(ctx, s)
+}
+
+// Topics returns the topics with handler functions.
+func (h *HandlerFuncs) Topics() []string {
+	var topics []string
+	for _, v := range []struct {
+		set   bool
+		topic string
+	}{
+		{h.Block != nil, TopicRawBlock},
+		{h.RollbackBlock != nil, TopicRawBlockRollback},
+		{h.Op != nil, TopicRawOp},
+		{h.RollbackOp != nil, TopicRawOpRollback},
+		{h.Status != nil, TopicStatus},
+	} {
+		if v.set {
+			topics = append(topics, v.topic)
+		}
+	}
+	return topics
+}
+
+// TypeResolver loads contract type info for operations, e.g. index.OpAPI.
+type TypeResolver interface {
+	ResolveTypes(context.Context, ...*Op) error
+}
+
+// Router decodes stream messages and dispatches them to a handler.
+//
+//	r := zmq.NewRouter(&zmq.HandlerFuncs{
+//		Op: func(ctx context.Context, op *zmq.Op) error { ... },
+//		RollbackOp: func(ctx context.Context, op *zmq.Op) error { ... },
+//	}).WithTypeResolver(client.Op)
+//	err := r.Run(ctx, client.Zmq.NewSubscriber(addr, r.Topics()...))
+type Router struct {
+	handler  Handler
+	fields   map[string][]string
+	resolver TypeResolver
+}
+
+func NewRouter(h Handler) *Router {
+	return &Router{
+		handler: h,
+		fields:  make(map[string][]string),
+	}
+}
+
+// WithFields sets a custom column layout for messages on topic, e.g. when
+// the server was configured to publish other columns. Layouts for raw_block
+// and raw_op also apply to their rollback topics unless these have their
+// own layout.
+func (r *Router) WithFields(topic string, fields []string) *Router {
+	r.fields[topic] = fields
+	return r
+}
+
+// WithTypeResolver resolves contract types of operations before they are
+// passed to the handler, so that parameters and storage can be decoded.
+func (r *Router) WithTypeResolver(res TypeResolver) *Router {
+	r.resolver = res
+	return r
+}
+
+// Topics returns the topics the handler is interested in. These are all
+// topics unless the handler tells otherwise.
+func (r *Router) Topics() []string {
+	if t, ok := r.handler.(interface{ Topics() []string }); ok {
+		return t.Topics()
+	}
+	return AllTopics
+}
+
+// Source delivers messages to a handler, e.g. Subscriber or Player.
+type Source interface {
+	Run(context.Context, func(*Message) error) error
+}
+
+var (
+	_ Source = (*Subscriber)(nil)
+	_ Source = (*Player)(nil)
+)
+
+// Run receives messages from src and dispatches them until ctx is canceled
+// or the handler fails.
+func (r *Router) Run(ctx context.Context, src Source) error {
+	return src.Run(ctx, func(m *Message) error {
+		return r.Handle(ctx, m)
+	})
+}
+
+// Handle decodes a single message and calls the matching handler. Messages
+// on unknown topics are ignored. Unlike Message.DecodeOp and friends the
+// router skips columns without struct field, so it keeps working when the
+// server publishes new columns. A panicking handler is reported as error.
+func (r *Router) Handle(ctx context.Context, m *Message) (err error) {
+	if f, ok := r.fields[m.topic]; ok {
+		m.WithFields(f)
+	} else if f, ok := r.fields[strings.TrimSuffix(m.topic, "/rollback")]; ok {
+		m.WithFields(f)
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("zmq: %s: handler panic: %v", m.topic, e)
+		}
+	}()
+	switch m.topic {
+	case TopicRawBlock, TopicRawBlockRollback:
+		b, err := decodeMessage[Block](m, client.DecodeLenient)
+		if err != nil {
+			return fmt.Errorf("zmq: %s: %w", m.topic, err)
+		}
+		if m.IsRollback() {
+			return r.handler.OnRollbackBlock(ctx, b)
+		}
+		return r.handler.OnBlock(ctx, b)
+	case TopicRawOp, TopicRawOpRollback:
+		o, err := decodeMessage[Op](m, client.DecodeLenient)
+		if err != nil {
+			return fmt.Errorf("zmq: %s: %w", m.topic, err)
+		}
+		if r.resolver != nil {
+			if err := r.resolver.ResolveTypes(ctx, o); err != nil {
+				return fmt.Errorf("zmq: resolving types for %s: %w", o.Hash, err)
+			}
+		}
+		if m.IsRollback() {
+			return r.handler.OnRollbackOp(ctx, o)
+		}
+		return r.handler.OnOp(ctx, o)
+	case TopicStatus:
+		s, err := decodeMessage[Status](m, client.DecodeLenient)
+		if err != nil {
+			return fmt.Errorf("zmq: %s: %w", m.topic, err)
+		}
+		return r.handler.OnStatus(ctx, s)
+	}
+	return nil
+}
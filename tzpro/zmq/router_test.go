@@ -0,0 +1,247 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var (
+	testBlockFields  = []string{"height", "unknown"}
+	testOpFields     = []string{"id", "height", "status", "unknown"}
+	testStatusFields = []string{"status", "blocks"}
+)
+
+// routerMessage returns a message with a small column layout. The last
+// column is unknown to the SDK.
+func routerMessage(topic, body string) *Message {
+	m := NewMessage([]byte(topic), []byte(body))
+	switch strings.TrimSuffix(topic, "/rollback") {
+	case TopicRawBlock:
+		m.WithFields(testBlockFields)
+	case TopicRawOp:
+		m.WithFields(testOpFields)
+	case TopicStatus:
+		m.WithFields(testStatusFields)
+	}
+	return m
+}
+
+// eventLog records handler calls as "event:height".
+type eventLog struct {
+	events []string
+}
+
+func (l *eventLog) handler() *HandlerFuncs {
+	block := func(name string) func(context.Context, *Block) error {
+		return func(_ context.Context, b *Block) error {
+			l.events = append(l.events, fmt.Sprintf("%s:%d", name, b.Height))
+			return nil
+		}
+	}
+	op := func(name string) func(context.Context, *Op) error {
+		return func(_ context.Context, o *Op) error {
+			l.events = append(l.events, fmt.Sprintf("%s:%d", name, o.Height))
+			return nil
+		}
+	}
+	return &HandlerFuncs{
+		Block:         block("block"),
+		RollbackBlock: block("rollback_block"),
+		Op:            op("op"),
+		RollbackOp:    op("rollback_op"),
+		Status: func(_ context.Context, s *Status) error {
+			l.events = append(l.events, fmt.Sprintf("status:%d", s.Blocks))
+			return nil
+		},
+	}
+}
+
+// sliceSource delivers messages in order.
+type sliceSource []*Message
+
+func (s sliceSource) Run(ctx context.Context, fn func(*Message) error) error {
+	for _, m := range s {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRouterDispatch(t *testing.T) {
+	var l eventLog
+	src := sliceSource{
+		routerMessage(TopicStatus, `["synced",9]`),
+		routerMessage(TopicRawBlock, `[10,"x"]`),
+		routerMessage(TopicRawOp, `[1,10,"applied",{"x":1}]`),
+		routerMessage(TopicRawOpRollback, `[1,10,"applied",null]`),
+		routerMessage(TopicRawBlockRollback, `[10,"x"]`),
+		routerMessage("raw_other", `[1]`),
+	}
+	if err := NewRouter(l.handler()).Run(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	want := "status:9 block:10 op:10 rollback_op:10 rollback_block:10"
+	if got := strings.Join(l.events, " "); got != want {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+
+	// unset handler functions are skipped and not subscribed
+	h := &HandlerFuncs{Op: l.handler().Op}
+	if err := NewRouter(h).Handle(context.Background(), routerMessage(TopicRawBlock, `[11,"x"]`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(NewRouter(h).Topics()); got != "[raw_op]" {
+		t.Errorf("topics %s", got)
+	}
+}
+
+func TestRouterFields(t *testing.T) {
+	var l eventLog
+	r := NewRouter(l.handler()).
+		WithFields(TopicRawBlock, []string{"cycle", "height"}).
+		WithFields(TopicRawOpRollback, []string{"height", "id"})
+	for _, m := range []*Message{
+		NewMessage([]byte(TopicRawBlock), []byte(`[1,20]`)),
+		NewMessage([]byte(TopicRawBlockRollback), []byte(`[1,20]`)),
+		NewMessage([]byte(TopicRawOpRollback), []byte(`[21,5]`)),
+	} {
+		if err := r.Handle(context.Background(), m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "block:20 rollback_block:20 rollback_op:21"
+	if got := strings.Join(l.events, " "); got != want {
+		t.Errorf("got events %q, want %q", got, want)
+	}
+}
+
+func TestMessageDecodeStrict(t *testing.T) {
+	m := routerMessage(TopicRawOp, `[1,10,"applied",1]`)
+	if _, err := m.DecodeOp(); err == nil || !strings.Contains(err.Error(), `"unknown"`) {
+		t.Errorf("got %v, want error for unknown column", err)
+	}
+	m = routerMessage(TopicRawBlock, `[10,1]`)
+	if _, err := m.DecodeBlock(); err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+	m = NewMessage([]byte(TopicStatus), []byte(`["synced",9,8,9,1.0]`))
+	if s, err := m.DecodeStatus(); err != nil || s.Blocks != 9 {
+		t.Errorf("got %+v %v", s, err)
+	}
+}
+
+type testResolver struct {
+	err      error
+	resolved []int64
+}
+
+func (r *testResolver) ResolveTypes(_ context.Context, ops ...*Op) error {
+	for _, o := range ops {
+		r.resolved = append(r.resolved, o.Height)
+	}
+	return r.err
+}
+
+func TestRouterErrors(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	tests := []struct {
+		name     string
+		msg      *Message
+		handler  *HandlerFuncs
+		resolver *testResolver
+		wantErr  string
+		wantIs   error
+	}{
+		{
+			name:    "block_type",
+			msg:     routerMessage(TopicRawBlock, `["ten",1]`),
+			wantErr: `zmq: raw_block: decode: row 0 column "height"`,
+		},
+		{
+			name:    "op_syntax",
+			msg:     routerMessage(TopicRawOp, `[1,10`),
+			wantErr: "zmq: raw_op: ",
+		},
+		{
+			name:    "op_status",
+			msg:     routerMessage(TopicRawOpRollback, `[1,10,"nope",1]`),
+			wantErr: `zmq: raw_op/rollback: decode: row 0 column "status"`,
+		},
+		{
+			name:    "status_type",
+			msg:     routerMessage(TopicStatus, `["synced","nine"]`),
+			wantErr: `zmq: status: decode: row 0 column "blocks"`,
+		},
+		{
+			name:     "resolver",
+			msg:      routerMessage(TopicRawOp, `[1,10,"applied",1]`),
+			resolver: &testResolver{err: errHandler},
+			wantErr:  "zmq: resolving types for",
+			wantIs:   errHandler,
+		},
+		{
+			name: "handler",
+			msg:  routerMessage(TopicStatus, `["synced",9]`),
+			handler: &HandlerFuncs{Status: func(context.Context, *Status) error {
+				return errHandler
+			}},
+			wantIs: errHandler,
+		},
+		{
+			name: "handler_panic",
+			msg:  routerMessage(TopicRawBlock, `[10,1]`),
+			handler: &HandlerFuncs{Block: func(_ context.Context, b *Block) error {
+				var m map[int64]bool
+				m[b.Height] = true
+				return nil
+			}},
+			wantErr: "zmq: raw_block: handler panic: assignment to entry in nil map",
+		},
+		{
+			name: "rollback_panic",
+			msg:  routerMessage(TopicRawOpRollback, `[1,10,"applied",1]`),
+			handler: &HandlerFuncs{RollbackOp: func(context.Context, *Op) error {
+				panic("boom")
+			}},
+			wantErr: "zmq: raw_op/rollback: handler panic: boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l eventLog
+			h := tt.handler
+			if h == nil {
+				h = l.handler()
+			}
+			r := NewRouter(h)
+			if tt.resolver != nil {
+				r.WithTypeResolver(tt.resolver)
+			}
+			// a failing message stops the source
+			src := sliceSource{tt.msg, routerMessage(TopicStatus, `["synced",9]`)}
+			err := r.Run(context.Background(), src)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.wantErr != "" && !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("got error %q, want %q", err, tt.wantErr)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("got error %v, want %v", err, tt.wantIs)
+			}
+			if len(l.events) > 0 {
+				t.Errorf("handler called with %v", l.events)
+			}
+			if tt.resolver != nil && fmt.Sprint(tt.resolver.resolved) != "[10]" {
+				t.Errorf("resolved %v", tt.resolver.resolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2024 Blockwatch Data Inc.
+// Author: alex@blockwatch.cc
+
+package zmq
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/echa/log"
+)
+
+var (
+	DefaultHeartbeat         = 10 * time.Second
+	DefaultIdleTimeout       = time.Minute
+	DefaultReconnectDelay    = time.Second
+	DefaultMaxReconnectDelay = time.Minute
+)
+
+// Subscriber receives messages from a TzPro ZMQ endpoint. It reconnects with
+// exponential backoff when the connection fails and detects dead
+// connections with heartbeats and an idle timeout.
+//
+//	sub := zmq.NewSubscriber("tcp://zmq.tzpro.io:9000", zmq.TopicRawBlock, zmq.TopicRawBlockRollback)
+//	err := sub.Run(ctx, func(m *zmq.Message) error {
+//		b, err := m.DecodeBlock()
+//		...
+//	})
+type Subscriber struct {
+	addr         string
+	topics       []string
+	heartbeat    time.Duration
+	timeout      time.Duration
+	reconnect    time.Duration
+	maxReconnect time.Duration
+	log          log.Logger
+}
+
+// NewSubscriber creates a subscriber for topics at addr. Without topics
+// all TzPro topics are subscribed.
+func NewSubscriber(addr string, topics ...string) *Subscriber {
+	if len(topics) == 0 {
+		topics = AllTopics
+	}
+	return &Subscriber{
+		addr:         addr,
+		topics:       topics,
+		heartbeat:    DefaultHeartbeat,
+		timeout:      DefaultIdleTimeout,
+		reconnect:    DefaultReconnectDelay,
+		maxReconnect: DefaultMaxReconnectDelay,
+		log:          log.Disabled,
+	}
+}
+
+// WithHeartbeat sets the heartbeat interval. Zero disables heartbeats.
+func (s *Subscriber) WithHeartbeat(d time.Duration) *Subscriber {
+	s.heartbeat = d
+	return s
+}
+
+// WithIdleTimeout sets how long the connection may stay silent before it
+// is considered dead. Zero disables the timeout.
+func (s *Subscriber) WithIdleTimeout(d time.Duration) *Subscriber {
+	s.timeout = d
+	return s
+}
+
+// WithReconnect sets the initial and max delay between reconnects.
+func (s *Subscriber) WithReconnect(delay, max time.Duration) *Subscriber {
+	s.reconnect, s.maxReconnect = delay, max
+	return s
+}
+
+func (s *Subscriber) WithLogger(l log.Logger) *Subscriber {
+	s.log = l
+	return s
+}
+
+// Topics returns the subscribed topics.
+func (s *Subscriber) Topics() []string {
+	return s.topics
+}
+
+// Run connects, subscribes and calls fn for every received message until
+// ctx is canceled or fn returns an error. ZMQ subscriptions match topic
+// prefixes, Run only delivers messages whose topic equals one of the
+// subscribed topics. Connection errors are logged and followed by a
+// reconnect.
+func (s *Subscriber) Run(ctx context.Context, fn func(*Message) error) error {
+	delay := s.reconnect
+	for {
+		n, err := s.session(ctx, fn)
+		var herr *handlerError
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.As(err, &herr):
+			return herr.err
+		}
+		if n > 0 {
+			// connection was healthy, start over with a short delay
+			delay = s.reconnect
+		}
+		s.log.Warnf("zmq: %s: %v, reconnecting in %s", s.addr, err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > s.maxReconnect {
+			delay = s.maxReconnect
+		}
+	}
+}
+
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// session runs a single connection and returns the number of delivered
+// messages.
+func (s *Subscriber) session(ctx context.Context, fn func(*Message) error) (int, error) {
+	c, err := Dial(ctx, s.addr)
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	for _, t := range s.topics {
+		if err := c.Subscribe(t); err != nil {
+			return 0, err
+		}
+	}
+	s.log.Debugf("zmq: connected to %s peer at %s", c.PeerType(), s.addr)
+
+	// close the connection on shutdown to unblock reads, send heartbeats
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		var tick <-chan time.Time
+		if s.heartbeat > 0 {
+			t := time.NewTicker(s.heartbeat)
+			defer t.Stop()
+			tick = t.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				c.Close()
+				return
+			case <-tick:
+				if err := c.Ping(2 * s.heartbeat); err != nil {
+					c.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	var n int
+	c.SetIdleTimeout(s.timeout)
+	for {
+		m, err := c.Recv()
+		if err != nil {
+			return n, err
+		}
+		if !s.match(m.topic) {
+			continue
+		}
+		n++
+		if err := fn(m); err != nil {
+			return n, &handlerError{err}
+		}
+	}
+}
+
+func (s *Subscriber) match(topic string) bool {
+	for _, t := range s.topics {
+		if t == topic || t == "" {
+			return true
+		}
+	}
+	return false
+}